@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/neet-007/chess_engine_go/internal/board"
+	"github.com/neet-007/chess_engine_go/internal/dataset"
+)
+
+// parseScoredFEN parses "<fen> | <score> | <result>", score is in centipawns and
+// result is 1.0, 0.5 or 0.0, both from white's point of view
+func parseScoredFEN(line string) (board.PackedPosition, error) {
+	parts := strings.Split(line, "|")
+	if len(parts) != 3 {
+		return board.PackedPosition{}, fmt.Errorf("Invalid scored FEN: %s expected fen | score | result", line)
+	}
+
+	b := board.NewBoard()
+	if err := parseFEN(b, strings.TrimSpace(parts[0])); err != nil {
+		return board.PackedPosition{}, err
+	}
+
+	score, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 16)
+	if err != nil {
+		return board.PackedPosition{}, fmt.Errorf("Invalid scored FEN: %s expected 16 bit score found %s", line, parts[1])
+	}
+
+	var result board.GameResult
+	switch strings.TrimSpace(parts[2]) {
+	case "1.0":
+		{
+			result = board.WhiteWin
+		}
+	case "0.5":
+		{
+			result = board.Draw
+		}
+	case "0.0":
+		{
+			result = board.BlackWin
+		}
+	default:
+		{
+			return board.PackedPosition{}, fmt.Errorf("Invalid scored FEN: %s expected result 1.0, 0.5 or 0.0 found %s", line, parts[2])
+		}
+	}
+
+	return b.Pack(int16(score), result)
+}
+
+func serializeScoredFEN(p board.PackedPosition) (string, error) {
+	b, err := p.Unpack()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s | %d | %s", serializeFEN(b), p.Score(), p.Result()), nil
+}
+
+type scoredFENReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *scoredFENReader) Read() (board.PackedPosition, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		p, err := parseScoredFEN(line)
+		if err != nil {
+			return p, fmt.Errorf("line %d: %w", r.line, err)
+		}
+
+		return p, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return board.PackedPosition{}, err
+	}
+
+	return board.PackedPosition{}, io.EOF
+}
+
+type convertOptions struct {
+	from    string
+	to      string
+	dedup   bool
+	shuffle int
+	seed    uint64
+}
+
+// convert streams positions between the scored FEN text format and the packed
+// binary format, optionally dropping duplicates and shuffling on the way
+func convert(in io.Reader, out io.Writer, options convertOptions) (int, error) {
+	var src dataset.Source
+
+	switch options.from {
+	case "text":
+		{
+			src = &scoredFENReader{scanner: bufio.NewScanner(in)}
+		}
+	case "packed":
+		{
+			src = dataset.NewReader(in)
+		}
+	default:
+		{
+			return 0, fmt.Errorf("Invalid input format: expected text or packed found %s", options.from)
+		}
+	}
+
+	if options.dedup {
+		src = dataset.NewDedupReader(src)
+	}
+
+	if options.shuffle > 0 {
+		src = dataset.NewShuffleReader(src, options.shuffle, options.seed)
+	}
+
+	var write func(p board.PackedPosition) error
+	var flush func() error
+
+	switch options.to {
+	case "text":
+		{
+			w := bufio.NewWriter(out)
+			write = func(p board.PackedPosition) error {
+				line, err := serializeScoredFEN(p)
+				if err != nil {
+					return err
+				}
+
+				_, err = fmt.Fprintln(w, line)
+				return err
+			}
+			flush = w.Flush
+		}
+	case "packed":
+		{
+			w := dataset.NewWriter(out)
+			write = w.Write
+			flush = w.Flush
+		}
+	default:
+		{
+			return 0, fmt.Errorf("Invalid output format: expected text or packed found %s", options.to)
+		}
+	}
+
+	count := 0
+	for {
+		p, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		// NOTE: flush on errors too so the records already written aren't lost
+		if err != nil {
+			return count, errors.Join(err, flush())
+		}
+
+		if err := write(p); err != nil {
+			return count, errors.Join(err, flush())
+		}
+		count++
+	}
+
+	return count, flush()
+}
+
+func runConvert(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+
+	options := convertOptions{}
+	flags.StringVar(&options.from, "from", "text", "input format, text or packed")
+	flags.StringVar(&options.to, "to", "packed", "output format, text or packed")
+	flags.BoolVar(&options.dedup, "dedup", false, "drop positions with an already seen Zobrist key, keeps every key in memory")
+	flags.IntVar(&options.shuffle, "shuffle", 0, "shuffle through a buffer of this many positions")
+	flags.Uint64Var(&options.seed, "seed", 0, "seed for -shuffle")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	_, err := convert(in, out, options)
+	return err
+}
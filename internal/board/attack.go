@@ -0,0 +1,129 @@
+package board
+
+import "math/bits"
+
+// AttackersTo returns the pieces of color that attack sq, sliders are blocked by
+// occupied so callers can look through pieces that are about to move
+func (b *Board) AttackersTo(sq uint8, color CurrentTurn, occupied uint64) uint64 {
+	offset := White_pawn
+	if color == BlackTurn {
+		offset = Black_pawn
+	}
+
+	queens := b.Bitboards[offset+White_queen]
+	rooks := b.Bitboards[offset+White_rook] | queens
+	bishops := b.Bitboards[offset+White_bishop] | queens
+
+	// NOTE: a pawn of color attacks sq if a pawn of the other color on sq would attack it
+	attackers := PawnAttacks[color^1][sq] & b.Bitboards[offset+White_pawn]
+	attackers |= KnightAttacks[sq] & b.Bitboards[offset+White_knight]
+	attackers |= KingAttacks[sq] & b.Bitboards[offset+White_king]
+	attackers |= Sliders.RookAttacks(int(sq), occupied) & rooks
+	attackers |= Sliders.BishopAttacks(int(sq), occupied) & bishops
+
+	return attackers
+}
+
+// InCheck reports whether color's king is attacked, a side without a king is
+// never in check and neither is an antichess king or an atomic king next to
+// the other king
+func (b *Board) InCheck(color CurrentTurn) bool {
+	king, other := b.Bitboards[White_king], b.Bitboards[Black_king]
+	if color == BlackTurn {
+		king, other = other, king
+	}
+
+	if king == 0 || b.Variant == Antichess {
+		return false
+	}
+
+	// NOTE: capturing a king next to your own would blow up both
+	if b.Variant == Atomic && KingAttacks[bits.TrailingZeros64(king)]&other != 0 {
+		return false
+	}
+
+	return b.AttackersTo(uint8(bits.TrailingZeros64(king)), color^1, b.Occupied) != 0
+}
+
+func (b *Board) removePiece(sq uint8) {
+	piece := Piece(b.Mailbox[sq])
+	if piece == No_piece {
+		return
+	}
+
+	b.Bitboards[piece] &^= 1 << sq
+	if piece < Black_pawn {
+		b.Bitboards[White_all] &^= 1 << sq
+	} else {
+		b.Bitboards[Black_all] &^= 1 << sq
+	}
+
+	b.Occupied &^= 1 << sq
+	b.Mailbox[sq] = No_piece
+}
+
+// afterMove returns a copy with the pieces moved for m by the side to move,
+// only the piece placement is updated, not the turn, flags, clocks or hands
+func (b *Board) afterMove(m Move) Board {
+	next := *b
+
+	offset := White_pawn
+	if b.CurrentTurn == BlackTurn {
+		offset = Black_pawn
+	}
+
+	from, to := uint8(m.From()), uint8(m.To())
+
+	if m.IsDrop() {
+		next.PutInSquare(GetFileIndex(to), GetRankIndex(to), m.DropPiece()+offset)
+		next.UpdateEmpty()
+		return next
+	}
+
+	piece := Piece(b.Mailbox[from])
+
+	switch uint16(m.Flags()) {
+	case EpCaptureFlag:
+		{
+			// NOTE: the captured pawn is beside from, on the file of to
+			next.removePiece(GetSquareIndex(GetFileIndex(to), GetRankIndex(from)))
+		}
+	case KingCastleFlag, QueenCastleFlag:
+		{
+			rookFrom, rookTo := GetSquareIndex(7, GetRankIndex(from)), GetSquareIndex(5, GetRankIndex(from))
+			if uint16(m.Flags()) == QueenCastleFlag {
+				rookFrom, rookTo = GetSquareIndex(0, GetRankIndex(from)), GetSquareIndex(3, GetRankIndex(from))
+			}
+
+			next.removePiece(rookFrom)
+			next.PutInSquare(GetFileIndex(rookTo), GetRankIndex(rookTo), offset+White_rook)
+		}
+	}
+
+	if m.IsPromotion() {
+		piece = offset + m.PromotionPiece()
+	}
+
+	capture := b.Occupied&(1<<to) != 0 || uint16(m.Flags()) == EpCaptureFlag
+
+	next.removePiece(from)
+	next.removePiece(to)
+	next.PutInSquare(GetFileIndex(to), GetRankIndex(to), piece)
+
+	if capture && b.Variant == Atomic {
+		for exploded := ExplosionMask(to, b.Bitboards[White_pawn]|b.Bitboards[Black_pawn]) & next.Occupied; exploded != 0; exploded &= exploded - 1 {
+			next.removePiece(uint8(bits.TrailingZeros64(exploded)))
+		}
+	}
+
+	next.UpdateEmpty()
+
+	return next
+}
+
+// GivesCheck reports whether m by the side to move attacks the other king,
+// directly or by uncovering a slider
+func (b *Board) GivesCheck(m Move) bool {
+	next := b.afterMove(m)
+	return next.InCheck(b.CurrentTurn ^ 1)
+}
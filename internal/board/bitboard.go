@@ -0,0 +1,147 @@
+package board
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// NOTE: same LERF layout as Board, bit 0 is a1 and bit 63 is h8
+type Bitboard uint64
+
+const (
+	FileA Bitboard = 0x0101010101010101
+	FileB Bitboard = FileA << 1
+	FileC Bitboard = FileA << 2
+	FileD Bitboard = FileA << 3
+	FileE Bitboard = FileA << 4
+	FileF Bitboard = FileA << 5
+	FileG Bitboard = FileA << 6
+	FileH Bitboard = FileA << 7
+
+	Rank1 Bitboard = 0xFF
+	Rank2 Bitboard = Rank1 << (8 * 1)
+	Rank3 Bitboard = Rank1 << (8 * 2)
+	Rank4 Bitboard = Rank1 << (8 * 3)
+	Rank5 Bitboard = Rank1 << (8 * 4)
+	Rank6 Bitboard = Rank1 << (8 * 5)
+	Rank7 Bitboard = Rank1 << (8 * 6)
+	Rank8 Bitboard = Rank1 << (8 * 7)
+)
+
+var Files = [8]Bitboard{FileA, FileB, FileC, FileD, FileE, FileF, FileG, FileH}
+var Ranks = [8]Bitboard{Rank1, Rank2, Rank3, Rank4, Rank5, Rank6, Rank7, Rank8}
+
+func SquareBitboard(sq Square) Bitboard {
+	return 1 << sq
+}
+
+func (b Bitboard) Has(sq Square) bool {
+	return b&(1<<sq) != 0
+}
+
+func (b *Bitboard) Set(sq Square) {
+	*b |= 1 << sq
+}
+
+func (b *Bitboard) Clear(sq Square) {
+	*b &^= 1 << sq
+}
+
+func (b Bitboard) Count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// LSB returns the lowest set square, No_square for an empty bitboard
+func (b Bitboard) LSB() Square {
+	if b == 0 {
+		return No_square
+	}
+
+	return Square(bits.TrailingZeros64(uint64(b)))
+}
+
+// PopLSB clears and returns the lowest set square, No_square for an empty bitboard
+func (b *Bitboard) PopLSB() Square {
+	sq := b.LSB()
+	*b &= *b - 1
+	return sq
+}
+
+// NOTE: east and west shifts mask the file they would wrap into
+func (b Bitboard) North() Bitboard {
+	return b << 8
+}
+
+func (b Bitboard) South() Bitboard {
+	return b >> 8
+}
+
+func (b Bitboard) East() Bitboard {
+	return (b &^ FileH) << 1
+}
+
+func (b Bitboard) West() Bitboard {
+	return (b &^ FileA) >> 1
+}
+
+func (b Bitboard) NorthEast() Bitboard {
+	return (b &^ FileH) << 9
+}
+
+func (b Bitboard) NorthWest() Bitboard {
+	return (b &^ FileA) << 7
+}
+
+func (b Bitboard) SouthEast() Bitboard {
+	return (b &^ FileH) >> 7
+}
+
+func (b Bitboard) SouthWest() Bitboard {
+	return (b &^ FileA) >> 9
+}
+
+func (b Bitboard) Shift(d Direction) Bitboard {
+	switch d {
+	case North:
+		return b.North()
+	case NorthEast:
+		return b.NorthEast()
+	case East:
+		return b.East()
+	case SouthEast:
+		return b.SouthEast()
+	case South:
+		return b.South()
+	case SouthWest:
+		return b.SouthWest()
+	case West:
+		return b.West()
+	case NorthWest:
+		return b.NorthWest()
+	default:
+		return 0
+	}
+}
+
+// String renders the bitboard as an 8x8 grid with rank 8 on top
+func (b Bitboard) String() string {
+	var builder strings.Builder
+
+	for rank := range uint8(8) {
+		rank = 7 - rank
+		for file := range uint8(8) {
+			if file > 0 {
+				builder.WriteString(" ")
+			}
+
+			if b.Has(Square(GetSquareIndex(file, rank))) {
+				builder.WriteString("1")
+			} else {
+				builder.WriteString(".")
+			}
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
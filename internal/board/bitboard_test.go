@@ -0,0 +1,84 @@
+package board
+
+import "testing"
+
+func TestBitboardShifts(t *testing.T) {
+	// NOTE: one square in each corner and one in the middle
+	b := Bitboard(squares(A1, H1, A8, H8, E4))
+
+	cases := []struct {
+		direction Direction
+		expected  uint64
+	}{
+		{North, squares(A2, H2, E5)},
+		{South, squares(A7, H7, E3)},
+		{East, squares(B1, B8, F4)},
+		{West, squares(G1, G8, D4)},
+		{NorthEast, squares(B2, F5)},
+		{NorthWest, squares(G2, D5)},
+		{SouthEast, squares(B7, F3)},
+		{SouthWest, squares(G7, D3)},
+	}
+
+	for _, c := range cases {
+		if found := b.Shift(c.direction); found != Bitboard(c.expected) {
+			t.Errorf("Direction %d: expected\n%s\nfound\n%s", c.direction, Bitboard(c.expected), found)
+		}
+	}
+
+	// NOTE: shifting a full board must never wrap onto the other edge
+	for direction := range Direction(8) {
+		full := ^Bitboard(0)
+		for sq := range 64 {
+			expected := Rays[direction.Opposite()][sq] != 0
+			if found := full.Shift(direction).Has(Square(sq)); found != expected {
+				t.Errorf("Direction %d square %s: expected %t found %t", direction, Square(sq), expected, found)
+			}
+		}
+	}
+}
+
+func TestBitboardBits(t *testing.T) {
+	var b Bitboard
+	b.Set(C3)
+	b.Set(A1)
+	b.Set(H8)
+	b.Set(C3)
+
+	if b.Count() != 3 || !b.Has(C3) || b.Has(C4) || b.LSB() != A1 {
+		t.Errorf("Unexpected bitboard after Set\n%s", b)
+	}
+
+	b.Clear(H8)
+	b.Clear(D4)
+
+	var popped []Square
+	for b != 0 {
+		popped = append(popped, b.PopLSB())
+	}
+
+	if len(popped) != 2 || popped[0] != A1 || popped[1] != C3 {
+		t.Errorf("Expected to pop a1 c3 found %v", popped)
+	}
+
+	if b.LSB() != No_square || b.PopLSB() != No_square {
+		t.Errorf("Expected No_square from an empty bitboard")
+	}
+
+	if Files[4]&Ranks[3] != SquareBitboard(E4) || FileA|FileH|Rank1|Rank8 != Bitboard(^uint64(0x007E7E7E7E7E7E00)) {
+		t.Errorf("Unexpected file and rank constants")
+	}
+
+	expected := "" +
+		". . . . . . . 1\n" +
+		". . . . . . . .\n" +
+		". . . . . . . .\n" +
+		". . . . . . . .\n" +
+		". . . . 1 . . .\n" +
+		". . . . . . . .\n" +
+		". . . . . . . .\n" +
+		"1 . . . . . . .\n"
+	if found := Bitboard(squares(A1, E4, H8)).String(); found != expected {
+		t.Errorf("Expected\n%s\nfound\n%s", expected, found)
+	}
+}
@@ -88,6 +88,38 @@ var KnightAttacks = [64]uint64{}
 var PawnAttacks = [2][64]uint64{}
 var KingAttacks = [64]uint64{}
 
+type Direction uint8
+
+const (
+	North Direction = iota
+	NorthEast
+	East
+	SouthEast
+	South
+	SouthWest
+	West
+	NorthWest
+)
+
+// NOTE: file and rank steps for each Direction
+var DirectionOffsets = [8][2]int{{0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}}
+
+func (d Direction) Opposite() Direction {
+	return (d + 4) & 7
+}
+
+// Rays holds the squares from a square to the edge of the board, not including the square
+var Rays = [8][64]uint64{}
+
+// Between holds the squares strictly between two squares on a shared line, 0 if not aligned
+var Between = [64][64]uint64{}
+
+// Line holds the whole line through two squares edge to edge, 0 if not aligned
+var Line = [64][64]uint64{}
+
+var Distance = [64][64]uint8{}
+var ManhattanDistance = [64][64]uint8{}
+
 type Square uint8
 
 func (s Square) String() string {
@@ -311,6 +343,12 @@ const (
 	CaptureFlag     uint16 = 4 // 0100
 	EpCaptureFlag   uint16 = 5 // 0101
 
+	// Crazyhouse drops, the from bits hold the dropped piece (pawn to queen)
+	DropFlag uint16 = 6 // 0110
+
+	// Antichess king promotions, with or without a capture, the board tells which
+	KingPromotionFlag uint16 = 7 // 0111
+
 	// Promotions (Bit 3 of the 4 bits is the "Promotion" bit)
 	KnightPromotionFlag uint16 = 8  // 1000
 	BishopPromotionFlag uint16 = 9  // 1001
@@ -340,6 +378,38 @@ func (m Move) Flags() int {
 	return int(m >> 12)
 }
 
+// NOTE: tests the flag bits in place so callers don't have to go through Flags(),
+// drops and king promotions (0110 and 0111) have the capture bit without being captures
+func (m Move) IsCapture() bool {
+	return m&Move(CaptureFlag<<12) != 0 && m>>13 != Move(DropFlag>>1)
+}
+
+func NewDropMove(piece Piece, to Square) Move {
+	return NewMove(Square(HandIndex(piece)), to, DropFlag)
+}
+
+func (m Move) IsDrop() bool {
+	return m>>12 == Move(DropFlag)
+}
+
+// DropPiece returns the dropped piece as a white piece, add Black_pawn for black
+func (m Move) DropPiece() Piece {
+	return Piece(m.From())
+}
+
+func (m Move) IsPromotion() bool {
+	return m&Move(KnightPromotionFlag<<12) != 0 || m>>12 == Move(KingPromotionFlag)
+}
+
+// PromotionPiece returns the white piece promoted to, only valid for promotions
+func (m Move) PromotionPiece() Piece {
+	if m>>12 == Move(KingPromotionFlag) {
+		return White_king
+	}
+
+	return White_knight + Piece(m.Flags()&3)
+}
+
 type CurrentTurn uint8
 
 const (
@@ -365,6 +435,15 @@ type Board struct {
 	HalfMoves   int
 	FullMoves   int
 	EpSquare    uint8
+	Variant     Variant
+
+	// NOTE: Crazyhouse only, Hand counts pawn to queen per color and Promoted
+	// marks pieces that go back to hand as pawns when captured
+	Hand     [2][5]uint8
+	Promoted uint64
+
+	// NOTE: Three-check only, checks given by each color
+	Checks [2]uint8
 }
 
 func NewBoard() *Board {
@@ -401,11 +480,11 @@ func GetRankIndex(index uint8) uint8 {
 	return uint8((index >> 3))
 }
 
-func (b *Board) PrintBoard() {
+func printMailbox(mailbox [64]uint8) {
 	for rank := range 8 {
 		rank = 7 - rank
 		for file := range 8 {
-			piece := b.Mailbox[rank*8+file]
+			piece := mailbox[rank*8+file]
 			if piece == No_piece {
 				fmt.Printf("*")
 			} else {
@@ -416,29 +495,25 @@ func (b *Board) PrintBoard() {
 	}
 }
 
+func (b *Board) PrintBoard() {
+	printMailbox(b.Mailbox)
+}
+
+// PrintBitboard prints the board as the bitboards see it, to compare with PrintBoard
 func (b *Board) PrintBitboard() {
 	var mailbox [64]uint8
-	for i := uint8(0); i < 8; i++ {
-		for j := uint8(0); j < 8; j++ {
-			mailbox[i*8+j] = No_piece
-		}
+	for i := range mailbox {
+		mailbox[i] = No_piece
 	}
 
 	for i := uint8(0); i < 12; i++ {
-		bitboard := b.Bitboards[i]
-		piece := Piece(i)
-
-		for j := uint8(0); j < 64; j++ {
-			if bitboard&(1<<j) != 0 {
-				mailbox[j] = uint8(piece)
-			}
+		bitboard := Bitboard(b.Bitboards[i])
+		for bitboard != 0 {
+			mailbox[bitboard.PopLSB()] = i
 		}
 	}
 
-	old := b.Mailbox
-	b.Mailbox = mailbox
-	b.PrintBoard()
-	b.Mailbox = old
+	printMailbox(mailbox)
 }
 
 func (b *Board) PutInSquare(file uint8, rank uint8, piece Piece) {
@@ -473,44 +548,47 @@ func RankDown(bitboard uint64, rank uint8) uint64 {
 
 func PushPawnOne(pawns uint64, empty uint64, color uint8) uint64 {
 	if color == 0 {
-		return (RankUp(pawns, 1)) & empty
+		return uint64(Bitboard(pawns).North()) & empty
 	} else {
-		return (RankDown(pawns, 1)) & empty
+		return uint64(Bitboard(pawns).South()) & empty
 	}
 }
 
 func PushPawnDouble(pawns uint64, empty uint64, color uint8) uint64 {
+	pushOne := Bitboard(PushPawnOne(pawns, empty, color))
 	if color == 0 {
-		rank4 := uint64(0x00000000FF000000)
-		pushOne := PushPawnOne(pawns, empty, color)
-		return RankUp(pushOne, 1) & empty & rank4
+		return uint64(pushOne.North()&Rank4) & empty
 	} else {
-		rank5 := uint64(0x000000FF00000000)
-		pushOne := PushPawnOne(pawns, empty, color)
-		return RankDown(pushOne, 1) & empty & rank5
+		return uint64(pushOne.South()&Rank5) & empty
 	}
 }
 
 func CanPushPawnSquares(pawns uint64, empty uint64, color uint8) uint64 {
 	if color == 0 {
-		return RankDown(empty, 1) & pawns
+		return uint64(Bitboard(empty).South()) & pawns
 	} else {
-		return RankUp(empty, 1) & pawns
+		return uint64(Bitboard(empty).North()) & pawns
 	}
 }
 
 func CanPushPawnDoubleSquares(pawns uint64, empty uint64, color uint8) uint64 {
 	if color == 0 {
-		rank4 := uint64(0x00000000FF000000)
-		emptyRank3 := RankDown(empty&rank4, 1) & empty
+		emptyRank3 := uint64(Bitboard(empty).South()&Rank3) & empty
 		return CanPushPawnSquares(pawns, emptyRank3, color)
 	} else {
-		rank5 := uint64(0x000000FF00000000)
-		emptyRank6 := RankUp(empty&rank5, 1) & empty
+		emptyRank6 := uint64(Bitboard(empty).North()&Rank6) & empty
 		return CanPushPawnSquares(pawns, emptyRank6, color)
 	}
 }
 
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
 func generateSlowAttacks(sq int, blockers uint64, isRook bool) uint64 {
 	attacks := uint64(0)
 
@@ -547,7 +625,7 @@ func generateSlowAttacks(sq int, blockers uint64, isRook bool) uint64 {
 }
 
 func init() {
-	InitMagics()
+	InitZobrist()
 
 	for position := range 64 {
 		f := int(GetFileIndex(uint8(position)))
@@ -587,6 +665,37 @@ func init() {
 				PawnAttacks[1][position] |= 1 << GetSquareIndex(uint8(targetF), uint8(targetR))
 			}
 		}
+
+		for direction, off := range DirectionOffsets {
+			for targetF, targetR := f+off[0], r+off[1]; targetF >= 0 && targetF < 8 && targetR >= 0 && targetR < 8; targetF, targetR = targetF+off[0], targetR+off[1] {
+				Rays[direction][position] |= 1 << GetSquareIndex(uint8(targetF), uint8(targetR))
+			}
+		}
+
+		for target := range 64 {
+			df := abs(f - int(GetFileIndex(uint8(target))))
+			dr := abs(r - int(GetRankIndex(uint8(target))))
+
+			Distance[position][target] = uint8(max(df, dr))
+			ManhattanDistance[position][target] = uint8(df + dr)
+		}
+	}
+
+	// NOTE: needs all the rays above
+	for position := range 64 {
+		for direction := range Direction(8) {
+			ray := Rays[direction][position]
+			line := ray | Rays[direction.Opposite()][position] | 1<<position
+
+			for target := range 64 {
+				if ray&(1<<target) == 0 {
+					continue
+				}
+
+				Between[position][target] = ray &^ Rays[direction][target] &^ (1 << target)
+				Line[position][target] = line
+			}
+		}
 	}
 
 	for position := range RookRelevantMasks {
@@ -595,16 +704,17 @@ func init() {
 		file := GetFileIndex(uint8(position))
 		rank := GetRankIndex(uint8(position))
 
-		for i := file + 1; i < 7; i++ {
+		// NOTE: loop on int, file-1 and rank-1 wrap around as uint8 on the edges
+		for i := int(file) + 1; i < 7; i++ {
 			mask |= 1 << GetSquareIndex(uint8(i), rank)
 		}
-		for i := file - 1; i > 0; i-- {
+		for i := int(file) - 1; i > 0; i-- {
 			mask |= 1 << GetSquareIndex(uint8(i), rank)
 		}
-		for i := rank + 1; i < 7; i++ {
+		for i := int(rank) + 1; i < 7; i++ {
 			mask |= 1 << GetSquareIndex(file, uint8(i))
 		}
-		for i := rank - 1; i > 0; i-- {
+		for i := int(rank) - 1; i > 0; i-- {
 			mask |= 1 << GetSquareIndex(file, uint8(i))
 		}
 
@@ -617,59 +727,23 @@ func init() {
 		file := GetFileIndex(uint8(position))
 		rank := GetRankIndex(uint8(position))
 
-		for i, j := file+1, rank+1; i < 7 && j < 7; i, j = i+1, j+1 {
+		for i, j := int(file)+1, int(rank)+1; i < 7 && j < 7; i, j = i+1, j+1 {
 			mask |= 1 << GetSquareIndex(uint8(i), uint8(j))
 		}
-		for i, j := file-1, rank+1; i > 0 && j < 7; i, j = i-1, j+1 {
+		for i, j := int(file)-1, int(rank)+1; i > 0 && j < 7; i, j = i-1, j+1 {
 			mask |= 1 << GetSquareIndex(uint8(i), uint8(j))
 		}
-		for i, j := file+1, rank-1; i < 7 && j > 0; i, j = i+1, j-1 {
+		for i, j := int(file)+1, int(rank)-1; i < 7 && j > 0; i, j = i+1, j-1 {
 			mask |= 1 << GetSquareIndex(uint8(i), uint8(j))
 		}
-		for i, j := file-1, rank-1; i > 0 && j > 0; i, j = i-1, j-1 {
+		for i, j := int(file)-1, int(rank)-1; i > 0 && j > 0; i, j = i-1, j-1 {
 			mask |= 1 << GetSquareIndex(uint8(i), uint8(j))
 		}
 
 		BishopRelevantMasks[position] = mask
 	}
 
-	for sq := range 64 {
-		rookMask := RookRelevantMasks[sq]
-		occupancy := uint64(0)
-
-		for {
-			attacks := generateSlowAttacks(sq, occupancy, true)
-
-			magic := RookMagics[sq]
-			shift := RookShifts[sq]
-			offset := RookTableOffsets[sq]
-			index := (uint64(occupancy) * magic) >> shift
-
-			RookTable[offset+int(index)] = attacks
-
-			occupancy = (occupancy - rookMask) & rookMask
-			if occupancy == 0 {
-				break
-			}
-		}
-
-		bishopMask := BishopRelevantMasks[sq]
-		occupancy = uint64(0)
-
-		for {
-			attacks := generateSlowAttacks(sq, occupancy, false)
-
-			magic := BishopMagics[sq]
-			shift := BishopShifts[sq]
-			offset := BishopTableOffsets[sq]
-			index := (uint64(occupancy) * magic) >> shift
-
-			BishopTable[offset+int(index)] = attacks
-
-			occupancy = (occupancy - bishopMask) & bishopMask
-			if occupancy == 0 {
-				break
-			}
-		}
-	}
+	// NOTE: needs the relevant masks above
+	InitMagics()
+	Sliders = defaultSliderBackend()
 }
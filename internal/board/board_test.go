@@ -0,0 +1,65 @@
+package board
+
+import "testing"
+
+func squares(list ...Square) uint64 {
+	var bitboard uint64
+	for _, sq := range list {
+		bitboard |= 1 << sq
+	}
+
+	return bitboard
+}
+
+func TestGeometry(t *testing.T) {
+	cases := []struct {
+		name     string
+		found    uint64
+		expected uint64
+	}{
+		{"Between a1 h8", Between[A1][H8], squares(B2, C3, D4, E5, F6, G7)},
+		{"Between e1 e4", Between[E1][E4], squares(E2, E3)},
+		{"Between h3 c3", Between[H3][C3], squares(D3, E3, F3, G3)},
+		{"Between e4 f5", Between[E4][F5], 0},
+		{"Between a1 b3", Between[A1][B3], 0},
+		{"Line b2 d4", Line[B2][D4], squares(A1, B2, C3, D4, E5, F6, G7, H8)},
+		{"Line c1 c5", Line[C1][C5], squares(C1, C2, C3, C4, C5, C6, C7, C8)},
+		{"Line a1 b3", Line[A1][B3], 0},
+		{"Rays north e4", Rays[North][E4], squares(E5, E6, E7, E8)},
+		{"Rays southwest e4", Rays[SouthWest][E4], squares(D3, C2, B1)},
+		{"Rays west a4", Rays[West][A4], 0},
+	}
+
+	for _, c := range cases {
+		if c.found != c.expected {
+			t.Errorf("%s: expected %x found %x", c.name, c.expected, c.found)
+		}
+	}
+
+	if Distance[A1][H8] != 7 || ManhattanDistance[A1][H8] != 14 || Distance[E4][G3] != 2 || ManhattanDistance[E4][G3] != 3 {
+		t.Errorf("Unexpected distances a1-h8 %d %d e4-g3 %d %d", Distance[A1][H8], ManhattanDistance[A1][H8], Distance[E4][G3], ManhattanDistance[E4][G3])
+	}
+
+	for a := range 64 {
+		for b := range 64 {
+			if Between[a][b] != Between[b][a] || Line[a][b] != Line[b][a] {
+				t.Fatalf("Expected %s %s to be symmetric", Square(a), Square(b))
+			}
+
+			if Between[a][b]&^Line[a][b] != 0 {
+				t.Fatalf("Expected Between %s %s inside Line", Square(a), Square(b))
+			}
+
+			for _, isRook := range []bool{true, false} {
+				if generateSlowAttacks(a, 0, isRook)&(1<<b) == 0 {
+					continue
+				}
+
+				expected := generateSlowAttacks(a, 1<<b, isRook) & generateSlowAttacks(b, 1<<a, isRook)
+				if Between[a][b] != expected {
+					t.Fatalf("Between %s %s: expected %x found %x", Square(a), Square(b), expected, Between[a][b])
+				}
+			}
+		}
+	}
+}
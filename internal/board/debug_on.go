@@ -0,0 +1,5 @@
+//go:build debug
+
+package board
+
+const debugBuild = true
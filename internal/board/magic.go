@@ -3,41 +3,41 @@ package board
 import "math/bits"
 
 var RookMagics = [64]uint64{
-	0xa8002c862a041044, 0x10441c0008408204, 0x101010101102020, 0x2100140210004000,
-	0x4110802000a01100, 0x44101080800010, 0x10302020401012, 0x10008200100042,
-	0x22202020004120, 0x211020080004020, 0x211020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x211020080004020, 0x211020080004020,
-	0x211020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
-	0x201020080004020, 0x201020080004020, 0x201020080004020, 0x201020080004020,
+	0x80068051e04000, 0x40001000402000, 0x80100020008008, 0x4e000a0010208440,
+	0x4200040802002010, 0x100010008020400, 0x9080608019000600, 0x8100020080204100,
+	0x4103800480400020, 0x8015004004802100, 0x200108a002040, 0x801000821001000,
+	0x15000500080070, 0x120800400800200, 0x109000432001100, 0x20080055b000080,
+	0x80004000402002, 0x5260848020004008, 0x2402020014402080, 0x3000808010000802,
+	0x304018004810800, 0x808004000200, 0x2040001500248, 0x12020000408401,
+	0x8440008080004020, 0x804200840100040, 0x820008080201000, 0x2080100100082100,
+	0x1000500100800, 0xa1000900028400, 0x100100400c80102, 0x1120000a044,
+	0x800080c004800620, 0x4040081000202000, 0xd08802008801000, 0x1000800800801004,
+	0x1004000801010010, 0x402800400800200, 0x4080204008110, 0x404082000401,
+	0xc0118861408000, 0x1100220081020048, 0x9a0430420050010, 0x82200420010,
+	0x2110080004008080, 0x2004201040680104, 0x1106001451820008, 0x2224104820014,
+	0x800c8044210500, 0x2a0200040100040, 0x40100a0001e4100, 0x204023108a0200,
+	0x2400080080040080, 0x1289008400020900, 0x2088250010400, 0x1006084010200,
+	0x1023480002141, 0x6400021810015, 0x8400100840200101, 0x40003000a1000825,
+	0x1002011008200402, 0x100d000400080201, 0x20048806102904, 0x8401000020804201,
 }
 
 var BishopMagics = [64]uint64{
-	0x89a1121896040240, 0x2048482c80410080, 0x610092244010040, 0x40220801008200,
-	0x401080820200100, 0x110004010c801100, 0x112004040801200, 0x1004020051200,
-	0x2028202a0020200, 0x4100880320824, 0x40120280403, 0x440a080130200,
-	0x120820400010020, 0x210c081088000, 0x4010201100812, 0x110021004010a00,
-	0x100011044100, 0x20010111100, 0x10122110202000, 0x41108210400,
-	0x40810c1010002, 0x111080100040, 0x10010101010008, 0x8021020013011,
-	0x10201202004002, 0x10120204000, 0x210102100021, 0x100040080080080,
-	0x1004010010040, 0x10080010041, 0x1020080081100, 0x200202101001,
-	0x200800110021, 0x801010110, 0x800800801000, 0x1020404011,
-	0x40104080a0010, 0x1101040010242, 0x11005201040802, 0x80080801000802,
-	0x40102001008400, 0x201000402000, 0x1001001100040, 0x100101000,
-	0x120010020102, 0x40021002004, 0x4010100108200, 0x1001010010010,
-	0x210408200010, 0x100210202001, 0x4002081011, 0x211021040001,
-	0x20a0104100, 0x4080081001, 0x81010102, 0x104004100,
-	0x2040404040412, 0x81001100040400, 0x40483010000, 0x210410411,
-	0x400020810, 0x200010011, 0x40483010000, 0x800104008,
+	0x4c40240122060016, 0x8048110404004a80, 0x8004440410414020, 0x21c410060405000,
+	0x80cd1040d0480812, 0x2021104000082, 0x8440082a8200001, 0x202a0800841002,
+	0x200c40810842088, 0x60c0081000c08901, 0xa3d0040042510c, 0x1c00110400808541,
+	0x400820211084005, 0x8860080800, 0x2002020202c000, 0x400344e08040a81,
+	0x812800102098a080, 0x202010823a2040, 0x4086400800830201, 0x5008012a22004000,
+	0x4801c00a00000, 0x400200505400, 0x480408401080820, 0x8000400029082824,
+	0x8880804501000, 0x1600048084100, 0x108220624040400, 0x8080000820002,
+	0xc804040010410041, 0x1080a0040208400, 0x2018030480a88800, 0x4040410020410810,
+	0x1108044010100210, 0x84a100400029800, 0x801080100820c00, 0x8010400808108200,
+	0x84008400020500, 0x2004200290481, 0x10150200032090, 0x8404042220404102,
+	0x302080308004008, 0x1200420820000408, 0x802002024200800, 0x4020824208000084,
+	0x2020c008200, 0x2c40208081000882, 0x2082223441000401, 0x8804080081101020,
+	0x4401011002220808, 0x81020c4202100000, 0x4005004404040308, 0x820400c42020001,
+	0x20206421820010, 0x150401001424008, 0x2a20242020c0608, 0x5020110109011200,
+	0x2050840108410401, 0x100090880842108, 0x220008960142187a, 0x1111028880208820,
+	0x4400200042028200, 0x4400010802084206, 0x400242040100, 0x2201104010944,
 }
 
 var (
@@ -68,7 +68,47 @@ func InitMagics() {
 		rookOffset += (1 << rBits)
 		bishopOffset += (1 << bBits)
 
-		// fillRookTable(sq)
-		// fillBishopTable(sq)
+		fillRookTable(sq)
+		fillBishopTable(sq)
 	}
 }
+
+func fillRookTable(sq int) {
+	mask := RookRelevantMasks[sq]
+	occupancy := uint64(0)
+
+	for {
+		index := (occupancy * RookMagics[sq]) >> RookShifts[sq]
+		RookTable[RookTableOffsets[sq]+int(index)] = generateSlowAttacks(sq, occupancy, true)
+
+		occupancy = (occupancy - mask) & mask
+		if occupancy == 0 {
+			break
+		}
+	}
+}
+
+func fillBishopTable(sq int) {
+	mask := BishopRelevantMasks[sq]
+	occupancy := uint64(0)
+
+	for {
+		index := (occupancy * BishopMagics[sq]) >> BishopShifts[sq]
+		BishopTable[BishopTableOffsets[sq]+int(index)] = generateSlowAttacks(sq, occupancy, false)
+
+		occupancy = (occupancy - mask) & mask
+		if occupancy == 0 {
+			break
+		}
+	}
+}
+
+func GetRookAttacks(sq int, occupied uint64) uint64 {
+	index := ((occupied & RookRelevantMasks[sq]) * RookMagics[sq]) >> RookShifts[sq]
+	return RookTable[RookTableOffsets[sq]+int(index)]
+}
+
+func GetBishopAttacks(sq int, occupied uint64) uint64 {
+	index := ((occupied & BishopRelevantMasks[sq]) * BishopMagics[sq]) >> BishopShifts[sq]
+	return BishopTable[BishopTableOffsets[sq]+int(index)]
+}
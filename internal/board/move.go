@@ -0,0 +1,258 @@
+package board
+
+import "fmt"
+
+// NOTE: a1a1 can never be a real move so the zero value doubles as the null move
+const NullMove Move = 0
+
+// MaxMoves is above the most legal moves any chess position has (218)
+const MaxMoves = 256
+
+// MoveList is a fixed capacity list so move generation doesn't allocate
+type MoveList struct {
+	Moves [MaxMoves]Move
+	Count int
+}
+
+func (l *MoveList) Add(m Move) {
+	l.Moves[l.Count] = m
+	l.Count++
+}
+
+func (l *MoveList) Len() int {
+	return l.Count
+}
+
+func (l *MoveList) Clear() {
+	l.Count = 0
+}
+
+func (l *MoveList) Slice() []Move {
+	return l.Moves[:l.Count]
+}
+
+var promotionChars = [4]byte{'n', 'b', 'r', 'q'}
+
+// UCI returns the long algebraic form used by UCI, for example e2e4 or e7e8q
+func (m Move) UCI() string {
+	if m == NullMove {
+		return "0000"
+	}
+
+	if m.IsDrop() {
+		return m.DropPiece().String() + "@" + Square(m.To()).String()
+	}
+
+	s := Square(m.From()).String() + Square(m.To()).String()
+	if m.IsPromotion() {
+		s += (m.PromotionPiece() + Black_pawn).String()
+	}
+
+	return s
+}
+
+func (m Move) String() string {
+	return m.UCI()
+}
+
+func parseSquare(s string) (Square, error) {
+	if len(s) != 2 || s[0] < 'a' || s[0] > 'h' || s[1] < '1' || s[1] > '8' {
+		return No_square, fmt.Errorf("Invalid square: expected a1-h8 found %s", s)
+	}
+
+	return Square(GetSquareIndex(s[0]-'a', s[1]-'1')), nil
+}
+
+func parseDrop(b *Board, s string) (Move, error) {
+	if b.Variant != Crazyhouse {
+		return NullMove, fmt.Errorf("Invalid move %s: drops are only allowed in crazyhouse", s)
+	}
+
+	piece, ok := CharToPiece[rune(s[0])]
+	if !ok || piece != piece%Black_pawn || piece == White_king {
+		return NullMove, fmt.Errorf("Invalid move %s: expected P, N, B, R or Q to drop", s)
+	}
+
+	to, err := parseSquare(s[2:4])
+	if err != nil {
+		return NullMove, fmt.Errorf("Invalid move %s: %w", s, err)
+	}
+
+	if b.Occupied&(1<<to) != 0 {
+		return NullMove, fmt.Errorf("Invalid move %s: %s is occupied", s, to)
+	}
+
+	if b.Hand[b.CurrentTurn][HandIndex(piece)] == 0 {
+		return NullMove, fmt.Errorf("Invalid move %s: no %s in hand", s, piece)
+	}
+
+	if rank := GetRankIndex(uint8(to)); piece == White_pawn && (rank == 0 || rank == 7) {
+		return NullMove, fmt.Errorf("Invalid move %s: pawns can't be dropped on the first or last rank", s)
+	}
+
+	return NewDropMove(piece, to), nil
+}
+
+// ParseUCIMove turns a UCI move into a Move for the side to move, working out the
+// flags from the board. It only checks that the piece can move that way, not that
+// the move leaves the king safe or that castling squares are unattacked.
+func ParseUCIMove(b *Board, s string) (Move, error) {
+	if s == "0000" {
+		return NullMove, nil
+	}
+
+	// NOTE: drops are written with an uppercase piece for both colors, like N@f3
+	if len(s) == 4 && s[1] == '@' {
+		return parseDrop(b, s)
+	}
+
+	if len(s) != 4 && len(s) != 5 {
+		return NullMove, fmt.Errorf("Invalid move: expected from, to and promotion found %s", s)
+	}
+
+	from, err := parseSquare(s[0:2])
+	if err != nil {
+		return NullMove, fmt.Errorf("Invalid move %s: %w", s, err)
+	}
+
+	to, err := parseSquare(s[2:4])
+	if err != nil {
+		return NullMove, fmt.Errorf("Invalid move %s: %w", s, err)
+	}
+
+	own, enemy := b.Bitboards[White_all], b.Bitboards[Black_all]
+	offset, forward := White_pawn, 1
+	if b.CurrentTurn == BlackTurn {
+		own, enemy = enemy, own
+		offset, forward = Black_pawn, -1
+	}
+
+	if own&(1<<from) == 0 {
+		return NullMove, fmt.Errorf("Invalid move %s: no piece of the side to move on %s", s, from)
+	}
+	if own&(1<<to) != 0 {
+		return NullMove, fmt.Errorf("Invalid move %s: %s is occupied by the side to move", s, to)
+	}
+
+	piece := Piece(b.Mailbox[from]) - offset
+	capture := enemy&(1<<to) != 0
+
+	var flags uint16
+	if capture {
+		flags = CaptureFlag
+	}
+
+	fromFile, fromRank := int(GetFileIndex(uint8(from))), int(GetRankIndex(uint8(from)))
+	toFile, toRank := int(GetFileIndex(uint8(to))), int(GetRankIndex(uint8(to)))
+
+	switch piece {
+	case White_pawn:
+		{
+			color := 0
+			if forward < 0 {
+				color = 1
+			}
+
+			switch {
+			case fromFile == toFile && toRank == fromRank+forward && !capture:
+			case fromFile == toFile && toRank == fromRank+2*forward && !capture && (fromRank == 1 || fromRank == 6 || (fromRank == 0 && forward > 0 && b.Variant == Horde)):
+				{
+					if b.Occupied&(1<<GetSquareIndex(uint8(fromFile), uint8(fromRank+forward))) != 0 {
+						return NullMove, fmt.Errorf("Invalid move %s: double push is blocked", s)
+					}
+
+					flags = DoublePushFlag
+
+					// NOTE: a horde first rank double push never sets an en passant square, keep it quiet
+					if fromRank == 0 {
+						flags = QuietMoveFlag
+					}
+				}
+			case PawnAttacks[color][from]&(1<<to) != 0 && capture:
+			case PawnAttacks[color][from]&(1<<to) != 0 && uint8(to) == b.EpSquare:
+				{
+					flags = EpCaptureFlag
+				}
+			default:
+				{
+					return NullMove, fmt.Errorf("Invalid move %s: pawn can't move from %s to %s", s, from, to)
+				}
+			}
+
+			lastRank := toRank == 7 || toRank == 0
+			if lastRank != (len(s) == 5) {
+				return NullMove, fmt.Errorf("Invalid move %s: promotion piece must be given exactly on the last rank", s)
+			}
+
+			if lastRank && s[4] == 'k' && b.Variant == Antichess {
+				return NewMove(from, to, KingPromotionFlag), nil
+			}
+
+			if lastRank {
+				promotion := -1
+				for i, c := range promotionChars {
+					if s[4] == c {
+						promotion = i
+					}
+				}
+
+				if promotion < 0 {
+					return NullMove, fmt.Errorf("Invalid move %s: expected promotion to n, b, r or q found %c", s, s[4])
+				}
+
+				flags |= KnightPromotionFlag | uint16(promotion)
+			}
+
+			return NewMove(from, to, flags), nil
+		}
+	case White_king:
+		{
+			// NOTE: castling is the only two file king move
+			if len(s) == 4 && fromRank == toRank && abs(toFile-fromFile) == 2 {
+				kingside := toFile > fromFile
+
+				flag := uint8(WhiteCastleQueenside)
+				if kingside {
+					flag = WhiteCastleKingside
+				}
+				if forward < 0 {
+					flag += BlackCastleKingside
+				}
+
+				rookSquare := GetSquareIndex(0, uint8(fromRank))
+				if kingside {
+					rookSquare = GetSquareIndex(7, uint8(fromRank))
+				}
+
+				if !b.GetFlag(flag) || b.Occupied&Between[from][rookSquare] != 0 {
+					return NullMove, fmt.Errorf("Invalid move %s: can't castle", s)
+				}
+
+				if kingside {
+					return NewMove(from, to, KingCastleFlag), nil
+				}
+				return NewMove(from, to, QueenCastleFlag), nil
+			}
+		}
+	}
+
+	var attacks uint64
+	switch piece {
+	case White_knight:
+		attacks = KnightAttacks[from]
+	case White_bishop:
+		attacks = Sliders.BishopAttacks(int(from), b.Occupied)
+	case White_rook:
+		attacks = Sliders.RookAttacks(int(from), b.Occupied)
+	case White_queen:
+		attacks = Sliders.BishopAttacks(int(from), b.Occupied) | Sliders.RookAttacks(int(from), b.Occupied)
+	case White_king:
+		attacks = KingAttacks[from]
+	}
+
+	if len(s) == 5 || attacks&(1<<to) == 0 {
+		return NullMove, fmt.Errorf("Invalid move %s: %s can't move from %s to %s", s, Piece(b.Mailbox[from]), from, to)
+	}
+
+	return NewMove(from, to, flags), nil
+}
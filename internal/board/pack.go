@@ -0,0 +1,122 @@
+package board
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// NOTE: PackedPosition layout, all multi byte fields are little endian
+//
+//	[0:8]   occupancy bitboard
+//	[8:24]  4 bit piece codes in occupancy order (LSB first), low nibble first
+//	[24]    bit 0 side to move, bits 1-4 castling flags
+//	[25]    en passant square, No_square if none
+//	[26]    half moves, clamped to 255
+//	[27:29] full moves
+//	[29:31] score, int16 from white's point of view
+//	[31]    game result
+const PackedSize = 32
+
+type PackedPosition [PackedSize]byte
+
+type GameResult uint8
+
+const (
+	BlackWin GameResult = iota
+	Draw
+	WhiteWin
+)
+
+func (r GameResult) String() string {
+	switch r {
+	case BlackWin:
+		return "0.0"
+	case Draw:
+		return "0.5"
+	case WhiteWin:
+		return "1.0"
+	default:
+		return "?"
+	}
+}
+
+func (b *Board) Pack(score int16, result GameResult) (PackedPosition, error) {
+	var p PackedPosition
+
+	if b.Variant != Standard {
+		return p, fmt.Errorf("Invalid position: packed positions only support standard chess found %s", b.Variant)
+	}
+
+	occupied := b.Bitboards[White_all] | b.Bitboards[Black_all]
+	if count := bits.OnesCount64(occupied); count > 32 {
+		return p, fmt.Errorf("Invalid position: expected at most 32 pieces found %d", count)
+	}
+
+	binary.LittleEndian.PutUint64(p[0:8], occupied)
+
+	i := 0
+	for occ := occupied; occ != 0; occ &= occ - 1 {
+		sq := bits.TrailingZeros64(occ)
+		piece := b.Mailbox[sq]
+		if piece > uint8(Black_king) {
+			return p, fmt.Errorf("Invalid position: no piece in mailbox on occupied square %s", Square(sq))
+		}
+
+		p[8+i/2] |= piece << (4 * (i % 2))
+		i++
+	}
+
+	p[24] = uint8(b.CurrentTurn) | (b.Flags&0xF)<<1
+	p[25] = b.EpSquare
+	p[26] = uint8(min(b.HalfMoves, 255))
+	binary.LittleEndian.PutUint16(p[27:29], uint16(min(b.FullMoves, 0xFFFF)))
+	binary.LittleEndian.PutUint16(p[29:31], uint16(score))
+	p[31] = uint8(result)
+
+	return p, nil
+}
+
+func (p PackedPosition) Unpack() (*Board, error) {
+	b := NewBoard()
+
+	occupied := binary.LittleEndian.Uint64(p[0:8])
+	if count := bits.OnesCount64(occupied); count > 32 {
+		return nil, fmt.Errorf("Invalid packed position: expected at most 32 pieces found %d", count)
+	}
+
+	i := 0
+	for occ := occupied; occ != 0; occ &= occ - 1 {
+		sq := uint8(bits.TrailingZeros64(occ))
+		piece := Piece((p[8+i/2] >> (4 * (i % 2))) & 0xF)
+		if piece > Black_king {
+			return nil, fmt.Errorf("Invalid packed position: unknown piece code %d on %s", piece, Square(sq))
+		}
+
+		b.PutInSquare(GetFileIndex(sq), GetRankIndex(sq), piece)
+		i++
+	}
+
+	b.UpdateEmpty()
+
+	b.CurrentTurn = CurrentTurn(p[24] & 1)
+	b.Flags = (p[24] >> 1) & 0xF
+
+	b.EpSquare = p[25]
+	if b.EpSquare > uint8(H8) && b.EpSquare != No_square {
+		return nil, fmt.Errorf("Invalid packed position: en passant square %d", b.EpSquare)
+	}
+
+	b.HalfMoves = int(p[26])
+	b.FullMoves = int(binary.LittleEndian.Uint16(p[27:29]))
+
+	return b, nil
+}
+
+func (p PackedPosition) Score() int16 {
+	return int16(binary.LittleEndian.Uint16(p[29:31]))
+}
+
+func (p PackedPosition) Result() GameResult {
+	return GameResult(p[31])
+}
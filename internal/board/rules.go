@@ -0,0 +1,364 @@
+package board
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Rules is what a variant changes on top of standard chess, every variant
+// embeds standardRules and overrides only the parts that differ
+type Rules interface {
+	// Validate checks the variant specific parts of a position, it runs after
+	// the shared bitboard and mailbox checks
+	Validate(b *Board) error
+	// Allows filters pseudo-legal moves that the variant forbids, it runs on
+	// top of the usual check on leaving the king attacked
+	Allows(b *Board, m Move) bool
+	// Result reports a game end that the variant adds, mate and stalemate are
+	// left to the caller since they need the legal moves
+	Result(b *Board) (GameResult, bool)
+}
+
+var variantRules = [...]Rules{
+	Standard:      standardRules{},
+	Crazyhouse:    standardRules{},
+	ThreeCheck:    threeCheckRules{},
+	KingOfTheHill: kingOfTheHillRules{},
+	RacingKings:   racingKingsRules{},
+	Atomic:        atomicRules{},
+	Antichess:     antichessRules{},
+	Horde:         hordeRules{},
+}
+
+func (v Variant) Rules() Rules {
+	if int(v) < len(variantRules) {
+		return variantRules[v]
+	}
+
+	return standardRules{}
+}
+
+type standardRules struct{}
+
+func (standardRules) Validate(b *Board) error {
+	if count := bits.OnesCount64(b.Bitboards[White_king]); count != 1 {
+		return fmt.Errorf("Invalid board: expected 1 white king found %d", count)
+	}
+	if count := bits.OnesCount64(b.Bitboards[Black_king]); count != 1 {
+		return fmt.Errorf("Invalid board: expected 1 black king found %d", count)
+	}
+
+	return nil
+}
+
+func (standardRules) Allows(b *Board, m Move) bool {
+	return true
+}
+
+func (standardRules) Result(b *Board) (GameResult, bool) {
+	return Draw, false
+}
+
+func winFor(color CurrentTurn) GameResult {
+	if color == WhiteTurn {
+		return WhiteWin
+	}
+
+	return BlackWin
+}
+
+// threeCheckRules: giving the third check wins
+type threeCheckRules struct {
+	standardRules
+}
+
+func (threeCheckRules) Validate(b *Board) error {
+	if err := (standardRules{}).Validate(b); err != nil {
+		return err
+	}
+
+	if b.Checks[0] > 3 || b.Checks[1] > 3 {
+		return fmt.Errorf("Invalid board: expected at most 3 checks found %d and %d", b.Checks[0], b.Checks[1])
+	}
+
+	return nil
+}
+
+func (threeCheckRules) Result(b *Board) (GameResult, bool) {
+	for color, count := range b.Checks {
+		if count >= 3 {
+			return winFor(CurrentTurn(color)), true
+		}
+	}
+
+	return Draw, false
+}
+
+// NOTE: d4, e4, d5 and e5
+const centerSquares uint64 = 0x0000001818000000
+
+// kingOfTheHillRules: a king reaching the center wins
+type kingOfTheHillRules struct {
+	standardRules
+}
+
+func (kingOfTheHillRules) Result(b *Board) (GameResult, bool) {
+	if b.Bitboards[White_king]&centerSquares != 0 {
+		return WhiteWin, true
+	}
+	if b.Bitboards[Black_king]&centerSquares != 0 {
+		return BlackWin, true
+	}
+
+	return Draw, false
+}
+
+// racingKingsRules: no checks at all, the first king to the 8th rank wins and
+// black gets one more move to draw by reaching it too
+type racingKingsRules struct {
+	standardRules
+}
+
+func (racingKingsRules) Validate(b *Board) error {
+	if err := (standardRules{}).Validate(b); err != nil {
+		return err
+	}
+
+	if b.Bitboards[White_pawn]|b.Bitboards[Black_pawn] != 0 {
+		return fmt.Errorf("Invalid board: racing kings has no pawns")
+	}
+	if b.InCheck(WhiteTurn) || b.InCheck(BlackTurn) {
+		return fmt.Errorf("Invalid board: racing kings positions can't have a king in check")
+	}
+
+	return nil
+}
+
+func (racingKingsRules) Allows(b *Board, m Move) bool {
+	return !b.GivesCheck(m)
+}
+
+func (racingKingsRules) Result(b *Board) (GameResult, bool) {
+	white := b.Bitboards[White_king]&uint64(Rank8) != 0
+	black := b.Bitboards[Black_king]&uint64(Rank8) != 0
+
+	switch {
+	case white && black:
+		{
+			return Draw, true
+		}
+	case black:
+		{
+			return BlackWin, true
+		}
+	case white && (b.CurrentTurn == WhiteTurn || !blackKingCanReachGoal(b)):
+		{
+			return WhiteWin, true
+		}
+	}
+
+	return Draw, false
+}
+
+func blackKingCanReachGoal(b *Board) bool {
+	if b.Bitboards[Black_king] == 0 {
+		return false
+	}
+
+	king := uint8(bits.TrailingZeros64(b.Bitboards[Black_king]))
+	occupied := b.Occupied &^ (1 << king)
+
+	for targets := KingAttacks[king] & uint64(Rank8) &^ b.Bitboards[Black_all]; targets != 0; targets &= targets - 1 {
+		sq := uint8(bits.TrailingZeros64(targets))
+		if b.AttackersTo(sq, WhiteTurn, occupied&^(1<<sq)) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExplosionMask returns the squares cleared by an atomic capture on sq, the
+// square itself and every neighbour that isn't a pawn
+func ExplosionMask(sq uint8, pawns uint64) uint64 {
+	return 1<<sq | KingAttacks[sq]&^pawns
+}
+
+// atomicRules: captures explode, kings can't capture and blowing up the other
+// king wins
+type atomicRules struct {
+	standardRules
+}
+
+func (atomicRules) Validate(b *Board) error {
+	// NOTE: an exploded king ends the game so one side may already be missing it
+	white, black := bits.OnesCount64(b.Bitboards[White_king]), bits.OnesCount64(b.Bitboards[Black_king])
+	if white > 1 || black > 1 || white+black == 0 {
+		return fmt.Errorf("Invalid board: expected at most 1 king per side found %d and %d", white, black)
+	}
+
+	return nil
+}
+
+func (atomicRules) Allows(b *Board, m Move) bool {
+	if m.IsCapture() {
+		king, own := White_king, b.Bitboards[White_king]
+		if b.CurrentTurn == BlackTurn {
+			king, own = Black_king, b.Bitboards[Black_king]
+		}
+
+		if Piece(b.Mailbox[m.From()]) == king {
+			return false
+		}
+
+		// NOTE: blowing up your own king is never allowed, even if it takes the other one with it
+		if ExplosionMask(uint8(m.To()), b.Bitboards[White_pawn]|b.Bitboards[Black_pawn])&own != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (atomicRules) Result(b *Board) (GameResult, bool) {
+	if b.Bitboards[White_king] == 0 {
+		return BlackWin, true
+	}
+	if b.Bitboards[Black_king] == 0 {
+		return WhiteWin, true
+	}
+
+	return Draw, false
+}
+
+// antichessRules: captures are compulsory, the king is an ordinary piece and
+// the side that loses all its pieces wins
+type antichessRules struct {
+	standardRules
+}
+
+func (antichessRules) Validate(b *Board) error {
+	if b.Flags&0xF != 0 {
+		return fmt.Errorf("Invalid board: antichess has no castling")
+	}
+
+	return nil
+}
+
+func (antichessRules) Allows(b *Board, m Move) bool {
+	if capturesOn(b, m) {
+		return true
+	}
+
+	return !hasCapture(b)
+}
+
+func (antichessRules) Result(b *Board) (GameResult, bool) {
+	if b.Bitboards[White_all] == 0 {
+		return WhiteWin, true
+	}
+	if b.Bitboards[Black_all] == 0 {
+		return BlackWin, true
+	}
+
+	return Draw, false
+}
+
+// capturesOn reports whether m takes a piece, unlike Move.IsCapture it also
+// knows about king promotions, which don't record the capture in their flags
+func capturesOn(b *Board, m Move) bool {
+	if uint16(m.Flags()) == KingPromotionFlag {
+		return b.Occupied&(1<<m.To()) != 0
+	}
+
+	return m.IsCapture()
+}
+
+// hasCapture reports whether the side to move can take anything
+func hasCapture(b *Board) bool {
+	own, enemy := b.Bitboards[White_all], b.Bitboards[Black_all]
+	offset := White_pawn
+	if b.CurrentTurn == BlackTurn {
+		own, enemy = enemy, own
+		offset = Black_pawn
+	}
+
+	targets := enemy
+	if b.EpSquare != No_square {
+		targets |= 1 << b.EpSquare
+	}
+
+	for pieces := own; pieces != 0; pieces &= pieces - 1 {
+		sq := bits.TrailingZeros64(pieces)
+
+		var attacks uint64
+		switch Piece(b.Mailbox[sq]) - offset {
+		case White_pawn:
+			{
+				// NOTE: only pawns can take en passant
+				attacks = PawnAttacks[b.CurrentTurn][sq] & targets
+			}
+		case White_knight:
+			{
+				attacks = KnightAttacks[sq] & enemy
+			}
+		case White_bishop:
+			{
+				attacks = Sliders.BishopAttacks(sq, b.Occupied) & enemy
+			}
+		case White_rook:
+			{
+				attacks = Sliders.RookAttacks(sq, b.Occupied) & enemy
+			}
+		case White_queen:
+			{
+				attacks = (Sliders.BishopAttacks(sq, b.Occupied) | Sliders.RookAttacks(sq, b.Occupied)) & enemy
+			}
+		case White_king:
+			{
+				attacks = KingAttacks[sq] & enemy
+			}
+		}
+
+		if attacks != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hordeRules: white has pawns and no king, black wins by taking every white
+// piece and white wins by mating as usual
+type hordeRules struct {
+	standardRules
+}
+
+func (hordeRules) Validate(b *Board) error {
+	if count := bits.OnesCount64(b.Bitboards[White_king]); count != 0 {
+		return fmt.Errorf("Invalid board: expected no white king in horde found %d", count)
+	}
+	if count := bits.OnesCount64(b.Bitboards[Black_king]); count != 1 {
+		return fmt.Errorf("Invalid board: expected 1 black king found %d", count)
+	}
+
+	return nil
+}
+
+func (hordeRules) Result(b *Board) (GameResult, bool) {
+	if b.Bitboards[White_all] == 0 {
+		return BlackWin, true
+	}
+
+	return Draw, false
+}
+
+// Allows reports whether the variant permits m, see Rules
+func (b *Board) Allows(m Move) bool {
+	return b.Variant.Rules().Allows(b, m)
+}
+
+// Result reports a variant specific game end, see Rules
+func (b *Board) Result() (GameResult, bool) {
+	return b.Variant.Rules().Result(b)
+}
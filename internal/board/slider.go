@@ -0,0 +1,192 @@
+package board
+
+// SliderBackend looks up rook and bishop attacks, every backend gives the same
+// answers and only differs in table layout and index computation
+type SliderBackend interface {
+	Name() string
+	RookAttacks(sq int, occupied uint64) uint64
+	BishopAttacks(sq int, occupied uint64) uint64
+	// TableBytes is the size of the attack tables, for comparing cache footprint
+	TableBytes() int
+}
+
+// Sliders is the backend used by the rest of the engine, picked at compile
+// time, build with -tags pext for the software PEXT index or -tags kindergarten
+// for the small kindergarten tables
+var Sliders SliderBackend
+
+// MagicBackend uses RookTable and BishopTable, one block of 2^bits entries per
+// square at the RookTableOffsets and BishopTableOffsets
+type MagicBackend struct{}
+
+func (MagicBackend) Name() string {
+	return "magic"
+}
+
+func (MagicBackend) RookAttacks(sq int, occupied uint64) uint64 {
+	return GetRookAttacks(sq, occupied)
+}
+
+func (MagicBackend) BishopAttacks(sq int, occupied uint64) uint64 {
+	return GetBishopAttacks(sq, occupied)
+}
+
+func (MagicBackend) TableBytes() int {
+	return (len(RookTable) + len(BishopTable)) * 8
+}
+
+// pext packs the bits of value selected by mask into the low bits, the same
+// as the BMI2 instruction but done one mask bit at a time
+func pext(value uint64, mask uint64) uint64 {
+	result := uint64(0)
+
+	for bit := uint64(1); mask != 0; bit <<= 1 {
+		if value&mask&-mask != 0 {
+			result |= bit
+		}
+		mask &= mask - 1
+	}
+
+	return result
+}
+
+// PextBackend indexes each square's block by the relevant occupancy bits in
+// order, so it needs no magics and its blocks are always dense
+type PextBackend struct {
+	rookTable   []uint64
+	bishopTable []uint64
+}
+
+func NewPextBackend() *PextBackend {
+	p := &PextBackend{
+		rookTable:   make([]uint64, len(RookTable)),
+		bishopTable: make([]uint64, len(BishopTable)),
+	}
+
+	for sq := range 64 {
+		for _, isRook := range []bool{true, false} {
+			mask, table, offset := BishopRelevantMasks[sq], p.bishopTable, BishopTableOffsets[sq]
+			if isRook {
+				mask, table, offset = RookRelevantMasks[sq], p.rookTable, RookTableOffsets[sq]
+			}
+
+			occupancy := uint64(0)
+			for {
+				table[offset+int(pext(occupancy, mask))] = generateSlowAttacks(sq, occupancy, isRook)
+
+				occupancy = (occupancy - mask) & mask
+				if occupancy == 0 {
+					break
+				}
+			}
+		}
+	}
+
+	return p
+}
+
+func (p *PextBackend) Name() string {
+	return "pext"
+}
+
+func (p *PextBackend) RookAttacks(sq int, occupied uint64) uint64 {
+	return p.rookTable[RookTableOffsets[sq]+int(pext(occupied, RookRelevantMasks[sq]))]
+}
+
+func (p *PextBackend) BishopAttacks(sq int, occupied uint64) uint64 {
+	return p.bishopTable[BishopTableOffsets[sq]+int(pext(occupied, BishopRelevantMasks[sq]))]
+}
+
+func (p *PextBackend) TableBytes() int {
+	return (len(p.rookTable) + len(p.bishopTable)) * 8
+}
+
+// NOTE: c2 to h7, gathers the inner a-file bits into the top six bits
+const kindergartenFileMagic uint64 = 0x0080402010080400
+
+// KindergartenBackend looks up each line separately from one first rank table,
+// the line's inner occupancy is gathered into six bits with a multiply so the
+// tables are a few KiB instead of hundreds
+type KindergartenBackend struct {
+	firstRank [64][8]uint8
+	aFile     [64][8]uint64
+	fillUp    [64][8]uint64
+	diagonal  [64]uint64
+	anti      [64]uint64
+}
+
+func NewKindergartenBackend() *KindergartenBackend {
+	k := &KindergartenBackend{}
+
+	for inner := range 64 {
+		for file := range 8 {
+			attacks := generateSlowAttacks(file, uint64(inner)<<1, true) & uint64(Rank1)
+			k.firstRank[inner][file] = uint8(attacks)
+			k.fillUp[inner][file] = attacks * uint64(FileA)
+		}
+	}
+
+	innerFile := uint64(FileA &^ (Rank1 | Rank8))
+	occupancy := uint64(0)
+	for {
+		index := (occupancy * kindergartenFileMagic) >> 58
+		for rank := range 8 {
+			k.aFile[index][rank] = generateSlowAttacks(rank*8, occupancy, true) & uint64(FileA)
+		}
+
+		occupancy = (occupancy - innerFile) & innerFile
+		if occupancy == 0 {
+			break
+		}
+	}
+
+	for sq := range 64 {
+		file, rank := sq&7, sq>>3
+		for other := range 64 {
+			f, r := other&7, other>>3
+			if f-file == r-rank {
+				k.diagonal[sq] |= 1 << other
+			}
+			if f-file == rank-r {
+				k.anti[sq] |= 1 << other
+			}
+		}
+	}
+
+	return k
+}
+
+func (k *KindergartenBackend) rank(sq int, occupied uint64) uint64 {
+	shift := sq &^ 7
+	inner := (occupied >> (shift + 1)) & 63
+	return uint64(k.firstRank[inner][sq&7]) << shift
+}
+
+func (k *KindergartenBackend) file(sq int, occupied uint64) uint64 {
+	occupied = uint64(FileA&^(Rank1|Rank8)) & (occupied >> (sq & 7))
+	index := (occupied * kindergartenFileMagic) >> 58
+	return k.aFile[index][sq>>3] << (sq & 7)
+}
+
+// line looks up attacks along a diagonal, its files are gathered like a rank
+func (k *KindergartenBackend) line(sq int, occupied uint64, mask uint64) uint64 {
+	inner := uint64(^(FileA | FileH))
+	index := ((occupied & mask & inner) * uint64(FileB)) >> 58
+	return k.fillUp[index][sq&7] & mask
+}
+
+func (k *KindergartenBackend) Name() string {
+	return "kindergarten"
+}
+
+func (k *KindergartenBackend) RookAttacks(sq int, occupied uint64) uint64 {
+	return k.rank(sq, occupied) | k.file(sq, occupied)
+}
+
+func (k *KindergartenBackend) BishopAttacks(sq int, occupied uint64) uint64 {
+	return k.line(sq, occupied, k.diagonal[sq]) | k.line(sq, occupied, k.anti[sq])
+}
+
+func (k *KindergartenBackend) TableBytes() int {
+	return len(k.firstRank)*8 + (len(k.aFile)+len(k.fillUp))*8*8 + (len(k.diagonal)+len(k.anti))*8
+}
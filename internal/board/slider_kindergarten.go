@@ -0,0 +1,7 @@
+//go:build kindergarten
+
+package board
+
+func defaultSliderBackend() SliderBackend {
+	return NewKindergartenBackend()
+}
@@ -0,0 +1,7 @@
+//go:build !pext && !kindergarten
+
+package board
+
+func defaultSliderBackend() SliderBackend {
+	return MagicBackend{}
+}
@@ -0,0 +1,82 @@
+package board
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func sliderBackends() []SliderBackend {
+	return []SliderBackend{
+		MagicBackend{},
+		NewPextBackend(),
+		NewKindergartenBackend(),
+	}
+}
+
+func TestSliderBackends(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for _, backend := range sliderBackends() {
+		for sq := range 64 {
+			for _, isRook := range []bool{true, false} {
+				mask := BishopRelevantMasks[sq]
+				lookup := backend.BishopAttacks
+				if isRook {
+					mask = RookRelevantMasks[sq]
+					lookup = backend.RookAttacks
+				}
+
+				occupancy := uint64(0)
+				for {
+					// NOTE: bits outside the relevant mask must not change the result
+					noise := rng.Uint64() &^ mask
+					expected := generateSlowAttacks(sq, occupancy|noise, isRook)
+
+					if found := lookup(sq, occupancy|noise); found != expected {
+						t.Fatalf("%s: square %s rook %t occupancy %x expected %x found %x", backend.Name(), Square(sq), isRook, occupancy|noise, expected, found)
+					}
+
+					occupancy = (occupancy - mask) & mask
+					if occupancy == 0 {
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestKindergartenBackendSize(t *testing.T) {
+	kindergarten := NewKindergartenBackend()
+	plain := MagicBackend{}
+
+	if kindergarten.TableBytes() >= plain.TableBytes()/16 {
+		t.Errorf("Expected the kindergarten tables to be far below %d bytes found %d", plain.TableBytes(), kindergarten.TableBytes())
+	}
+}
+
+func BenchmarkSliders(b *testing.B) {
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	occupancies := make([]uint64, 1024)
+	for i := range occupancies {
+		occupancies[i] = rng.Uint64() & rng.Uint64()
+	}
+
+	for _, backend := range sliderBackends() {
+		b.Run(backend.Name(), func(b *testing.B) {
+			var sink uint64
+
+			for i := 0; i < b.N; i++ {
+				occupied := occupancies[i&1023]
+				sq := i & 63
+				sink ^= backend.RookAttacks(sq, occupied) ^ backend.BishopAttacks(sq, occupied)
+			}
+
+			b.ReportMetric(float64(backend.TableBytes()), "table-bytes")
+			if sink == 1 {
+				b.Log(sink)
+			}
+		})
+	}
+}
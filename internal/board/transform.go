@@ -0,0 +1,84 @@
+package board
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+func flipVertical(bitboard uint64) uint64 {
+	return bits.ReverseBytes64(bitboard)
+}
+
+func mirrorHorizontal(bitboard uint64) uint64 {
+	return bits.Reverse64(bits.ReverseBytes64(bitboard))
+}
+
+// ColorFlip swaps the colors of all pieces and mirrors the ranks, so the side
+// to move sees the same position from the other side of the board
+func (b *Board) ColorFlip() {
+	var bitboards [14]uint64
+	for piece := White_pawn; piece <= White_king; piece++ {
+		bitboards[piece] = flipVertical(b.Bitboards[piece+Black_pawn])
+		bitboards[piece+Black_pawn] = flipVertical(b.Bitboards[piece])
+	}
+	bitboards[White_all] = flipVertical(b.Bitboards[Black_all])
+	bitboards[Black_all] = flipVertical(b.Bitboards[White_all])
+	b.Bitboards = bitboards
+
+	b.Occupied = flipVertical(b.Occupied)
+	b.Empty = flipVertical(b.Empty)
+
+	var mailbox [64]uint8
+	for sq, piece := range b.Mailbox {
+		switch {
+		case piece == No_piece:
+			mailbox[sq^56] = No_piece
+		case piece < uint8(Black_pawn):
+			mailbox[sq^56] = piece + uint8(Black_pawn)
+		default:
+			mailbox[sq^56] = piece - uint8(Black_pawn)
+		}
+	}
+	b.Mailbox = mailbox
+
+	b.CurrentTurn ^= 1
+
+	// NOTE: white castling bits are 0-1 and black are 2-3
+	b.Flags = (b.Flags &^ 0xF) | (b.Flags&0x3)<<2 | (b.Flags>>2)&0x3
+
+	if b.EpSquare != No_square {
+		b.EpSquare ^= 56
+	}
+
+	b.Hand[0], b.Hand[1] = b.Hand[1], b.Hand[0]
+	b.Checks[0], b.Checks[1] = b.Checks[1], b.Checks[0]
+	b.Promoted = flipVertical(b.Promoted)
+}
+
+// MirrorHorizontal mirrors the files, castling rights don't survive a mirror so
+// positions that still have any are rejected
+func (b *Board) MirrorHorizontal() error {
+	if b.Flags&0xF != 0 {
+		return fmt.Errorf("Invalid mirror: position has castling rights")
+	}
+
+	for i := range b.Bitboards {
+		b.Bitboards[i] = mirrorHorizontal(b.Bitboards[i])
+	}
+
+	b.Occupied = mirrorHorizontal(b.Occupied)
+	b.Empty = mirrorHorizontal(b.Empty)
+	b.Promoted = mirrorHorizontal(b.Promoted)
+
+	var mailbox [64]uint8
+	for sq, piece := range b.Mailbox {
+		mailbox[sq^7] = piece
+	}
+	b.Mailbox = mailbox
+
+	if b.EpSquare != No_square {
+		b.EpSquare ^= 7
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package board
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Validate checks that the bitboards, mailbox and state flags describe one
+// consistent position, it returns the first problem found
+func (b *Board) Validate() error {
+	var union uint64
+	for piece := range Piece(12) {
+		if overlap := union & b.Bitboards[piece]; overlap != 0 {
+			return fmt.Errorf("Invalid board: %s overlaps another piece on %s", piece, Square(bits.TrailingZeros64(overlap)))
+		}
+
+		union |= b.Bitboards[piece]
+	}
+
+	var white, black uint64
+	for piece := White_pawn; piece <= White_king; piece++ {
+		white |= b.Bitboards[piece]
+	}
+	for piece := Black_pawn; piece <= Black_king; piece++ {
+		black |= b.Bitboards[piece]
+	}
+
+	if b.Bitboards[White_all] != white {
+		return fmt.Errorf("Invalid board: white_all %x expected %x", b.Bitboards[White_all], white)
+	}
+	if b.Bitboards[Black_all] != black {
+		return fmt.Errorf("Invalid board: black_all %x expected %x", b.Bitboards[Black_all], black)
+	}
+	if b.Occupied != union {
+		return fmt.Errorf("Invalid board: occupied %x expected %x", b.Occupied, union)
+	}
+	if b.Occupied != ^b.Empty {
+		return fmt.Errorf("Invalid board: occupied %x is not the complement of empty %x", b.Occupied, b.Empty)
+	}
+
+	for sq := range 64 {
+		expected := uint8(No_piece)
+		for piece := range Piece(12) {
+			if b.Bitboards[piece]&(1<<sq) != 0 {
+				expected = uint8(piece)
+				break
+			}
+		}
+
+		if b.Mailbox[sq] != expected {
+			return fmt.Errorf("Invalid board: mailbox has %s on %s expected %s", Piece(b.Mailbox[sq]), Square(sq), Piece(expected))
+		}
+	}
+
+	pawnsAndKings := b.Bitboards[White_pawn] | b.Bitboards[Black_pawn] | b.Bitboards[White_king] | b.Bitboards[Black_king]
+	if b.Promoted&^(b.Occupied&^pawnsAndKings) != 0 {
+		return fmt.Errorf("Invalid board: promoted %x marks squares without a knight, bishop, rook or queen", b.Promoted)
+	}
+
+	if b.Variant != Crazyhouse && (b.Promoted != 0 || b.Hand != [2][5]uint8{}) {
+		return fmt.Errorf("Invalid board: pieces in hand or promoted pieces outside crazyhouse")
+	}
+
+	for color := range b.Hand {
+		for index, count := range b.Hand[color] {
+			if count > HandLimits[index] {
+				return fmt.Errorf("Invalid board: %d %s in hand, at most %d", count, Piece(index+color*int(Black_pawn)), HandLimits[index])
+			}
+		}
+	}
+
+	if b.Variant != ThreeCheck && b.Checks != [2]uint8{} {
+		return fmt.Errorf("Invalid board: check counts outside three-check")
+	}
+
+	if err := b.Variant.Rules().Validate(b); err != nil {
+		return err
+	}
+
+	castling := []struct {
+		flag uint8
+		king Piece
+		rook Piece
+		from Square
+		rsq  Square
+	}{
+		{WhiteCastleKingside, White_king, White_rook, E1, H1},
+		{WhiteCastleQueenside, White_king, White_rook, E1, A1},
+		{BlackCastleKingside, Black_king, Black_rook, E8, H8},
+		{BlackCastleQueenside, Black_king, Black_rook, E8, A8},
+	}
+	for _, c := range castling {
+		if !b.GetFlag(c.flag) {
+			continue
+		}
+
+		if b.Bitboards[c.king]&(1<<c.from) == 0 || b.Bitboards[c.rook]&(1<<c.rsq) == 0 {
+			return fmt.Errorf("Invalid board: castling right needs %s on %s and %s on %s", c.king, c.from, c.rook, c.rsq)
+		}
+	}
+
+	if b.EpSquare != No_square {
+		if b.EpSquare > uint8(H8) {
+			return fmt.Errorf("Invalid board: en passant square %d", b.EpSquare)
+		}
+
+		// NOTE: the pawn that just double pushed sits one rank past the ep square
+		rank, pawn, pawnSq := uint8(5), Black_pawn, b.EpSquare-8
+		if b.CurrentTurn == BlackTurn {
+			rank, pawn, pawnSq = 2, White_pawn, b.EpSquare+8
+		}
+
+		if GetRankIndex(b.EpSquare) != rank {
+			return fmt.Errorf("Invalid board: en passant square %s is on the wrong rank", Square(b.EpSquare))
+		}
+		if b.Occupied&(1<<b.EpSquare) != 0 || b.Bitboards[pawn]&(1<<pawnSq) == 0 {
+			return fmt.Errorf("Invalid board: en passant square %s without a double pushed %s", Square(b.EpSquare), pawn)
+		}
+	}
+
+	return nil
+}
+
+// AssertValid panics on an invalid board in debug builds and does nothing
+// otherwise, build with -tags debug to enable it
+func (b *Board) AssertValid() {
+	if !debugBuild {
+		return
+	}
+
+	if err := b.Validate(); err != nil {
+		panic(err)
+	}
+}
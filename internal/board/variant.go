@@ -0,0 +1,76 @@
+package board
+
+import "fmt"
+
+type Variant uint8
+
+const (
+	Standard Variant = iota
+	Crazyhouse
+	ThreeCheck
+	KingOfTheHill
+	RacingKings
+	Atomic
+	Antichess
+	Horde
+)
+
+// NOTE: names are the UCI_Variant values
+var VariantNames = []string{"chess", "crazyhouse", "3check", "kingofthehill", "racingkings", "atomic", "antichess", "horde"}
+
+func (v Variant) String() string {
+	if int(v) < len(VariantNames) {
+		return VariantNames[v]
+	}
+
+	return "?"
+}
+
+func ParseVariant(name string) (Variant, error) {
+	for i, n := range VariantNames {
+		if n == name {
+			return Variant(i), nil
+		}
+	}
+
+	return Standard, fmt.Errorf("Unknown variant: %s", name)
+}
+
+// HandIndex maps a pawn to queen of either color to its slot in Board.Hand
+func HandIndex(piece Piece) int {
+	return int(piece % Black_pawn)
+}
+
+// HandLimits caps each slot of one side's hand, promoted pieces go back as pawns
+// so a hand never holds more than the other side's starting pieces plus pawns
+var HandLimits = [5]uint8{16, 4, 4, 4, 2}
+
+func (b *Board) AddToHand(piece Piece) {
+	color := 0
+	if piece >= Black_pawn {
+		color = 1
+	}
+
+	b.Hand[color][HandIndex(piece)]++
+}
+
+// CaptureToHand puts the piece on sq into the hand of the side capturing it,
+// promoted pieces go back as pawns
+func (b *Board) CaptureToHand(sq uint8) {
+	piece := Piece(b.Mailbox[sq])
+	if piece == No_piece {
+		return
+	}
+
+	// NOTE: the captured piece changes color when it joins the other hand
+	captured := White_pawn
+	if b.Promoted&(1<<sq) == 0 {
+		captured = piece % Black_pawn
+	}
+	if piece < Black_pawn {
+		captured += Black_pawn
+	}
+
+	b.AddToHand(captured)
+	b.Promoted &^= 1 << sq
+}
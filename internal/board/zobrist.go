@@ -0,0 +1,106 @@
+package board
+
+import "math/bits"
+
+var (
+	ZobristPieces   [12][64]uint64
+	ZobristTurn     uint64
+	ZobristCastling [16]uint64
+	ZobristEpFile   [8]uint64
+
+	// NOTE: crazyhouse only, indexed by color, piece and how many are in hand
+	ZobristHand     [2][5][17]uint64
+	ZobristPromoted [64]uint64
+
+	// NOTE: Three-check only, indexed by color and checks given
+	ZobristChecks [2][4]uint64
+)
+
+// NOTE: fixed seed so keys are the same on every run and across machines
+func InitZobrist() {
+	state := uint64(0x9E3779B97F4A7C15)
+	next := func() uint64 {
+		// splitmix64
+		state += 0x9E3779B97F4A7C15
+		z := state
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		return z ^ (z >> 31)
+	}
+
+	for piece := range ZobristPieces {
+		for sq := range ZobristPieces[piece] {
+			ZobristPieces[piece][sq] = next()
+		}
+	}
+
+	ZobristTurn = next()
+
+	for i := range ZobristCastling {
+		ZobristCastling[i] = next()
+	}
+
+	for i := range ZobristEpFile {
+		ZobristEpFile[i] = next()
+	}
+
+	for color := range ZobristHand {
+		for piece := range ZobristHand[color] {
+			// NOTE: an empty hand hashes to 0 so standard positions keep their keys
+			for count := 1; count < len(ZobristHand[color][piece]); count++ {
+				ZobristHand[color][piece][count] = next()
+			}
+		}
+	}
+
+	for sq := range ZobristPromoted {
+		ZobristPromoted[sq] = next()
+	}
+
+	for color := range ZobristChecks {
+		for count := 1; count < len(ZobristChecks[color]); count++ {
+			ZobristChecks[color][count] = next()
+		}
+	}
+}
+
+// Hash computes the Zobrist key of the position from scratch
+func (b *Board) Hash() uint64 {
+	var key uint64
+
+	for piece := range 12 {
+		bitboard := b.Bitboards[piece]
+		for bitboard != 0 {
+			sq := bits.TrailingZeros64(bitboard)
+			bitboard &= bitboard - 1
+
+			key ^= ZobristPieces[piece][sq]
+		}
+	}
+
+	if b.CurrentTurn == BlackTurn {
+		key ^= ZobristTurn
+	}
+
+	key ^= ZobristCastling[b.Flags&0xF]
+
+	if b.EpSquare != No_square {
+		key ^= ZobristEpFile[GetFileIndex(b.EpSquare)]
+	}
+
+	for color := range b.Hand {
+		for piece, count := range b.Hand[color] {
+			key ^= ZobristHand[color][piece][min(count, 16)]
+		}
+	}
+
+	for color, count := range b.Checks {
+		key ^= ZobristChecks[color][min(count, 3)]
+	}
+
+	for promoted := b.Promoted; promoted != 0; promoted &= promoted - 1 {
+		key ^= ZobristPromoted[bits.TrailingZeros64(promoted)]
+	}
+
+	return key
+}
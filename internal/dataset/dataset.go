@@ -0,0 +1,146 @@
+package dataset
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math/rand/v2"
+
+	"github.com/neet-007/chess_engine_go/internal/board"
+)
+
+// Source is anything that streams packed positions, Read returns io.EOF at the end
+type Source interface {
+	Read() (board.PackedPosition, error)
+}
+
+type Writer struct {
+	w     *bufio.Writer
+	Count int
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w: bufio.NewWriter(w),
+	}
+}
+
+func (w *Writer) Write(p board.PackedPosition) error {
+	if _, err := w.w.Write(p[:]); err != nil {
+		return err
+	}
+
+	w.Count++
+	return nil
+}
+
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Read returns io.ErrUnexpectedEOF if the stream ends in the middle of a position
+func (r *Reader) Read() (board.PackedPosition, error) {
+	var p board.PackedPosition
+
+	_, err := io.ReadFull(r.r, p[:])
+	return p, err
+}
+
+// ShuffleReader shuffles a stream through a fixed size buffer, positions can only
+// move by about the buffer size so it should be large compared to a game
+type ShuffleReader struct {
+	src    Source
+	buffer []board.PackedPosition
+	size   int
+	rng    *rand.Rand
+	done   bool
+}
+
+func NewShuffleReader(src Source, size int, seed uint64) *ShuffleReader {
+	size = max(size, 1)
+
+	return &ShuffleReader{
+		src:    src,
+		buffer: make([]board.PackedPosition, 0, size),
+		size:   size,
+		rng:    rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+func (s *ShuffleReader) Read() (board.PackedPosition, error) {
+	for !s.done && len(s.buffer) < s.size {
+		p, err := s.src.Read()
+		if errors.Is(err, io.EOF) {
+			s.done = true
+			break
+		}
+		if err != nil {
+			return p, err
+		}
+
+		s.buffer = append(s.buffer, p)
+	}
+
+	if len(s.buffer) == 0 {
+		return board.PackedPosition{}, io.EOF
+	}
+
+	i := s.rng.IntN(len(s.buffer))
+	p := s.buffer[i]
+
+	last := len(s.buffer) - 1
+	s.buffer[i] = s.buffer[last]
+	s.buffer = s.buffer[:last]
+
+	return p, nil
+}
+
+// DedupReader drops positions whose Zobrist key was already seen, the score
+// and result of the first occurrence are kept
+type DedupReader struct {
+	src     Source
+	seen    map[uint64]struct{}
+	Dropped int
+}
+
+// NewDedupReader keeps every key it has seen for the whole stream, which costs
+// about 10 to 25 bytes per distinct position, split large datasets before dedup
+func NewDedupReader(src Source) *DedupReader {
+	return &DedupReader{
+		src:  src,
+		seen: map[uint64]struct{}{},
+	}
+}
+
+func (d *DedupReader) Read() (board.PackedPosition, error) {
+	for {
+		p, err := d.src.Read()
+		if err != nil {
+			return p, err
+		}
+
+		b, err := p.Unpack()
+		if err != nil {
+			return p, err
+		}
+
+		key := b.Hash()
+		if _, ok := d.seen[key]; ok {
+			d.Dropped++
+			continue
+		}
+
+		d.seen[key] = struct{}{}
+		return p, nil
+	}
+}
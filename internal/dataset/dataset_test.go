@@ -0,0 +1,145 @@
+package dataset
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/neet-007/chess_engine_go/internal/board"
+)
+
+func positions(t *testing.T, n int) []board.PackedPosition {
+	var list []board.PackedPosition
+
+	for i := range n {
+		b := board.NewBoard()
+		b.PutInSquare(uint8(i%8), uint8(i/8), board.White_king)
+		b.PutInSquare(7, 7, board.Black_king)
+		b.UpdateEmpty()
+
+		p, err := b.Pack(int16(i), board.Draw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		list = append(list, p)
+	}
+
+	return list
+}
+
+func readAll(t *testing.T, src Source) []board.PackedPosition {
+	var list []board.PackedPosition
+
+	for {
+		p, err := src.Read()
+		if err == io.EOF {
+			return list
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		list = append(list, p)
+	}
+}
+
+func TestReaderWriter(t *testing.T) {
+	list := positions(t, 20)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, p := range list {
+		if err := w.Write(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	read := readAll(t, NewReader(bytes.NewReader(buf.Bytes())))
+	if len(read) != len(list) {
+		t.Fatalf("Expected %d positions found %d", len(list), len(read))
+	}
+	for i := range list {
+		if read[i] != list[i] {
+			t.Errorf("Position %d: expected %x found %x", i, list[i], read[i])
+		}
+	}
+
+	truncated := NewReader(bytes.NewReader(buf.Bytes()[:board.PackedSize+5]))
+	if _, err := truncated.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := truncated.Read(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF found %v", err)
+	}
+}
+
+type sliceSource struct {
+	list []board.PackedPosition
+}
+
+func (s *sliceSource) Read() (board.PackedPosition, error) {
+	if len(s.list) == 0 {
+		return board.PackedPosition{}, io.EOF
+	}
+
+	p := s.list[0]
+	s.list = s.list[1:]
+	return p, nil
+}
+
+func TestShuffleReader(t *testing.T) {
+	list := positions(t, 50)
+
+	first := readAll(t, NewShuffleReader(&sliceSource{list}, 16, 7))
+	second := readAll(t, NewShuffleReader(&sliceSource{list}, 16, 7))
+
+	if len(first) != len(list) {
+		t.Fatalf("Expected %d positions found %d", len(list), len(first))
+	}
+
+	seen := map[board.PackedPosition]bool{}
+	moved := false
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same order for the same seed at %d", i)
+		}
+		if first[i] != list[i] {
+			moved = true
+		}
+		seen[first[i]] = true
+	}
+
+	if len(seen) != len(list) || !moved {
+		t.Errorf("Expected a permutation of the input, %d unique, moved %t", len(seen), moved)
+	}
+	// NOTE: sizes below 1 are clamped, a buffer of one keeps the input order
+	if got := readAll(t, NewShuffleReader(&sliceSource{list}, -3, 7)); len(got) != len(list) || got[0] != list[0] {
+		t.Errorf("Expected a negative size to pass positions through in order")
+	}
+}
+
+func TestDedupReader(t *testing.T) {
+	list := positions(t, 10)
+
+	// NOTE: same position with a different score and clocks
+	b, err := list[3].Unpack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.HalfMoves = 12
+	duplicate, err := b.Pack(999, board.WhiteWin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dedup := NewDedupReader(&sliceSource{append(append([]board.PackedPosition{}, list...), duplicate, list[0])})
+	read := readAll(t, dedup)
+
+	if len(read) != len(list) || dedup.Dropped != 2 {
+		t.Errorf("Expected %d positions and 2 dropped found %d and %d", len(list), len(read), dedup.Dropped)
+	}
+}
@@ -0,0 +1,149 @@
+package match
+
+import (
+	"fmt"
+	"math"
+)
+
+type Result struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+func (r Result) Games() int {
+	return r.Wins + r.Draws + r.Losses
+}
+
+// Score is the mean points per game from the first engine's side
+func (r Result) Score() float64 {
+	games := r.Games()
+	if games == 0 {
+		return 0.5
+	}
+
+	return (float64(r.Wins) + float64(r.Draws)/2) / float64(games)
+}
+
+// NOTE: stands in for an outcome that hasn't happened yet, like fishtest's regularization
+const pseudoGames = 0.5
+
+// variance is the per game variance of the score. Outcomes with no games count
+// as pseudoGames, otherwise a sweep or all draws would have no variance at all
+func (r Result) variance() float64 {
+	if r.Games() == 0 {
+		return 0
+	}
+
+	w, d, l := float64(r.Wins), float64(r.Draws), float64(r.Losses)
+	for _, count := range []*float64{&w, &d, &l} {
+		if *count == 0 {
+			*count = pseudoGames
+		}
+	}
+
+	games := w + d + l
+	s := (w + d/2) / games
+	w, d, l = w/games, d/games, l/games
+
+	return w*(1-s)*(1-s) + d*(0.5-s)*(0.5-s) + l*s*s
+}
+
+func scoreToElo(score float64) float64 {
+	if score <= 0 {
+		return math.Inf(-1)
+	}
+	if score >= 1 {
+		return math.Inf(1)
+	}
+
+	return -400 * math.Log10(1/score-1)
+}
+
+func eloToScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// Elo returns the logistic Elo difference and the half width of its 95% interval
+func (r Result) Elo() (elo float64, margin float64) {
+	games := float64(r.Games())
+	s := r.Score()
+	elo = scoreToElo(s)
+
+	if games == 0 {
+		return 0, math.Inf(1)
+	}
+
+	stdev := math.Sqrt(r.variance() / games)
+	low := scoreToElo(s - 1.959964*stdev)
+	high := scoreToElo(s + 1.959964*stdev)
+
+	return elo, (high - low) / 2
+}
+
+func (r Result) String() string {
+	elo, margin := r.Elo()
+	return fmt.Sprintf("W %d D %d L %d Elo %.1f +/- %.1f", r.Wins, r.Draws, r.Losses, elo, margin)
+}
+
+type SPRTStatus uint8
+
+const (
+	SPRTContinue SPRTStatus = iota
+	SPRTAcceptH0
+	SPRTAcceptH1
+)
+
+func (s SPRTStatus) String() string {
+	switch s {
+	case SPRTContinue:
+		return "continue"
+	case SPRTAcceptH0:
+		return "H0 accepted"
+	case SPRTAcceptH1:
+		return "H1 accepted"
+	default:
+		return "?"
+	}
+}
+
+// NOTE: H0 is elo == Elo0, H1 is elo == Elo1, Alpha and Beta are the false
+// positive and false negative rates
+type SPRT struct {
+	Elo0  float64
+	Elo1  float64
+	Alpha float64
+	Beta  float64
+}
+
+// Bounds returns the log likelihood ratios where H0 and H1 are accepted
+func (s SPRT) Bounds() (lower float64, upper float64) {
+	return math.Log(s.Beta / (1 - s.Alpha)), math.Log((1 - s.Beta) / s.Alpha)
+}
+
+// LLR uses the normal approximation of the trinomial model
+func (s SPRT) LLR(r Result) float64 {
+	variance := r.variance()
+	if variance == 0 {
+		return 0
+	}
+
+	s0 := eloToScore(s.Elo0)
+	s1 := eloToScore(s.Elo1)
+
+	return float64(r.Games()) * (s1 - s0) * (2*r.Score() - s0 - s1) / (2 * variance)
+}
+
+func (s SPRT) Status(r Result) SPRTStatus {
+	llr := s.LLR(r)
+	lower, upper := s.Bounds()
+
+	if llr >= upper {
+		return SPRTAcceptH1
+	}
+	if llr <= lower {
+		return SPRTAcceptH0
+	}
+
+	return SPRTContinue
+}
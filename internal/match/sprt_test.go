@@ -0,0 +1,66 @@
+package match
+
+import (
+	"math"
+	"testing"
+)
+
+func TestElo(t *testing.T) {
+	cases := []struct {
+		result Result
+		elo    float64
+		margin float64
+	}{
+		{Result{Wins: 100, Draws: 100, Losses: 50}, 70.44, 33.69},
+		{Result{Wins: 50, Draws: 100, Losses: 50}, 0, 34.16},
+		{Result{Wins: 10, Draws: 30, Losses: 60}, -190.85, 62.05},
+	}
+
+	for _, c := range cases {
+		elo, margin := c.result.Elo()
+		if math.Abs(elo-c.elo) > 0.01 || math.Abs(margin-c.margin) > 0.01 {
+			t.Errorf("%+v: expected Elo %.2f +/- %.2f found %.2f +/- %.2f", c.result, c.elo, c.margin, elo, margin)
+		}
+	}
+
+	// NOTE: a sweep has no finite Elo but the margin must still be a number
+	for _, r := range []Result{{Wins: 30}, {Losses: 30}} {
+		if _, margin := r.Elo(); math.IsNaN(margin) {
+			t.Errorf("%+v: expected a margin found NaN", r)
+		}
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	sprt := SPRT{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05}
+
+	lower, upper := sprt.Bounds()
+	if math.Abs(lower+2.944) > 0.001 || math.Abs(upper-2.944) > 0.001 {
+		t.Errorf("Expected bounds -2.944 2.944 found %.3f %.3f", lower, upper)
+	}
+
+	cases := []struct {
+		result Result
+		llr    float64
+		status SPRTStatus
+	}{
+		{Result{Wins: 100, Draws: 100, Losses: 50}, 2.385, SPRTContinue},
+		{Result{Wins: 200, Draws: 200, Losses: 100}, 4.770, SPRTAcceptH1},
+		{Result{Wins: 50, Draws: 100, Losses: 100}, -2.754, SPRTContinue},
+		{Result{Wins: 100, Draws: 200, Losses: 200}, -5.508, SPRTAcceptH0},
+		{Result{Draws: 20}, -0.174, SPRTContinue},
+		{Result{Wins: 200}, 458.086, SPRTAcceptH1},
+		{Result{Losses: 200}, -471.460, SPRTAcceptH0},
+	}
+
+	for _, c := range cases {
+		llr := sprt.LLR(c.result)
+		if math.Abs(llr-c.llr) > 0.01 {
+			t.Errorf("%+v: expected LLR %.3f found %.3f", c.result, c.llr, llr)
+		}
+
+		if status := sprt.Status(c.result); status != c.status {
+			t.Errorf("%+v: expected %s found %s", c.result, c.status, status)
+		}
+	}
+}
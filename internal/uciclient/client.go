@@ -0,0 +1,366 @@
+package uciclient
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTimeout = errors.New("Timeout waiting for engine")
+	ErrExited  = errors.New("Engine exited")
+)
+
+type Engine struct {
+	Name    string
+	Author  string
+	Options []Option
+
+	// NOTE: how long Wait gives the engine to answer stop before killing it
+	StopGrace time.Duration
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string
+
+	writeMu sync.Mutex
+
+	// NOTE: exitErr is set once the process is reaped, read it only after done is closed
+	done    chan struct{}
+	exitErr error
+}
+
+// Start launches the engine binary, call Handshake before anything else
+func Start(path string, args ...string) (*Engine, error) {
+	cmd := exec.Command(path, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		StopGrace: time.Second,
+		cmd:       cmd,
+		stdin:     stdin,
+		lines:     make(chan string, 256),
+		done:      make(chan struct{}),
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			e.lines <- scanner.Text()
+		}
+		close(e.lines)
+
+		e.exitErr = cmd.Wait()
+		close(e.done)
+	}()
+
+	return e, nil
+}
+
+func (e *Engine) Send(command string) error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	_, err := io.WriteString(e.stdin, command+"\n")
+	return err
+}
+
+// readLine returns the next line from the engine, a zero timeout waits forever
+func (e *Engine) readLine(timeout time.Duration) (string, error) {
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	select {
+	case line, ok := <-e.lines:
+		{
+			if !ok {
+				return "", ErrExited
+			}
+
+			return line, nil
+		}
+	case <-timer:
+		{
+			return "", ErrTimeout
+		}
+	}
+}
+
+// readUntil passes lines to handle until it returns true or an error, the
+// whole loop shares one timeout
+func (e *Engine) readUntil(timeout time.Duration, handle func(line string) (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		// NOTE: readLine waits forever on a zero timeout so a spent deadline has to be caught here
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return ErrTimeout
+		}
+
+		line, err := e.readLine(remaining)
+		if err != nil {
+			return err
+		}
+
+		if done, err := handle(line); done || err != nil {
+			return err
+		}
+	}
+}
+
+// Handshake sends uci and collects id and option lines until uciok
+func (e *Engine) Handshake(timeout time.Duration) error {
+	if err := e.Send("uci"); err != nil {
+		return err
+	}
+
+	err := e.readUntil(timeout, func(line string) (bool, error) {
+		switch {
+		case line == "uciok":
+			{
+				return true, nil
+			}
+		case strings.HasPrefix(line, "id name "):
+			{
+				e.Name = strings.TrimPrefix(line, "id name ")
+			}
+		case strings.HasPrefix(line, "id author "):
+			{
+				e.Author = strings.TrimPrefix(line, "id author ")
+			}
+		case strings.HasPrefix(line, "option "):
+			{
+				option, err := parseOption(line)
+				if err != nil {
+					return false, err
+				}
+
+				e.Options = append(e.Options, option)
+			}
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("Handshake: %w", err)
+	}
+
+	return nil
+}
+
+// IsReady sends isready and waits for readyok, other lines are dropped
+func (e *Engine) IsReady(timeout time.Duration) error {
+	if err := e.Send("isready"); err != nil {
+		return err
+	}
+
+	err := e.readUntil(timeout, func(line string) (bool, error) {
+		return line == "readyok", nil
+	})
+	if err != nil {
+		return fmt.Errorf("IsReady: %w", err)
+	}
+
+	return nil
+}
+
+func (e *Engine) SetOption(name string, value string) error {
+	if value == "" {
+		return e.Send("setoption name " + name)
+	}
+
+	return e.Send("setoption name " + name + " value " + value)
+}
+
+func (e *Engine) NewGame() error {
+	return e.Send("ucinewgame")
+}
+
+// Position sends "position startpos" when fen is empty
+func (e *Engine) Position(fen string, moves []string) error {
+	var builder strings.Builder
+
+	if fen == "" {
+		builder.WriteString("position startpos")
+	} else {
+		builder.WriteString("position fen ")
+		builder.WriteString(fen)
+	}
+
+	if len(moves) > 0 {
+		builder.WriteString(" moves ")
+		builder.WriteString(strings.Join(moves, " "))
+	}
+
+	return e.Send(builder.String())
+}
+
+// Go starts a search with the given go arguments, for example "wtime 1000 btime 1000".
+// Parsed info lines are sent on infos when it is not nil, lines that don't fit are
+// dropped rather than holding up the bestmove, and infos is never closed so it can
+// be reused. The returned channel receives the bestmove, it is closed without a
+// value if the engine exits first. Don't call other reading methods until the
+// bestmove arrives.
+func (e *Engine) Go(args string, infos chan<- Info) (<-chan BestMove, error) {
+	command := "go"
+	if args != "" {
+		command += " " + args
+	}
+
+	if err := e.Send(command); err != nil {
+		return nil, err
+	}
+
+	bestmove := make(chan BestMove, 1)
+
+	go func() {
+		defer close(bestmove)
+
+		last := Info{}
+		for line := range e.lines {
+			switch {
+			case strings.HasPrefix(line, "info "):
+				{
+					info, err := ParseInfo(line)
+					if err != nil {
+						continue
+					}
+
+					if info.HasScore {
+						last = info
+					}
+
+					if infos != nil {
+						select {
+						case infos <- info:
+						default:
+						}
+					}
+				}
+			case strings.HasPrefix(line, "bestmove"):
+				{
+					best, err := parseBestMove(line)
+					if err != nil {
+						continue
+					}
+
+					best.Info = last
+					bestmove <- best
+					return
+				}
+			}
+		}
+	}()
+
+	return bestmove, nil
+}
+
+func (e *Engine) Stop() error {
+	return e.Send("stop")
+}
+
+// Wait waits for the bestmove of a search started by Go. On timeout the engine
+// is sent stop, and if it doesn't answer within StopGrace it is killed.
+func (e *Engine) Wait(bestmove <-chan BestMove, timeout time.Duration) (BestMove, error) {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case best, ok := <-bestmove:
+		{
+			if !ok {
+				return BestMove{}, ErrExited
+			}
+
+			return best, nil
+		}
+	case <-t.C:
+	}
+
+	if err := e.Stop(); err == nil {
+		grace := time.NewTimer(e.StopGrace)
+		defer grace.Stop()
+
+		select {
+		case best, ok := <-bestmove:
+			{
+				if ok {
+					return best, nil
+				}
+			}
+		case <-grace.C:
+		}
+	}
+
+	e.Kill()
+	return BestMove{}, ErrTimeout
+}
+
+// Quit asks the engine to exit and kills it if it is still running after
+// timeout, once the engine has exited every call returns its exit status
+func (e *Engine) Quit(timeout time.Duration) error {
+	select {
+	case <-e.done:
+		{
+			return e.exitErr
+		}
+	default:
+	}
+
+	sendErr := e.Send("quit")
+
+	// NOTE: the engine may exit on quit before Close, and cmd.Wait closes stdin itself
+	closeErr := e.stdin.Close()
+	if errors.Is(closeErr, os.ErrClosed) {
+		closeErr = nil
+	}
+
+	err := errors.Join(sendErr, closeErr)
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	for {
+		select {
+		case _, ok := <-e.lines:
+			{
+				if !ok {
+					<-e.done
+					return errors.Join(err, e.exitErr)
+				}
+			}
+		case <-t.C:
+			{
+				e.Kill()
+				return ErrTimeout
+			}
+		}
+	}
+}
+
+func (e *Engine) Kill() error {
+	return e.cmd.Process.Kill()
+}
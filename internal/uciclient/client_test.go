@@ -0,0 +1,272 @@
+package uciclient
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// NOTE: the test binary doubles as the stub engine when this is set
+const stubEnv = "UCICLIENT_STUB_ENGINE"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(stubEnv) == "1" {
+		runStubEngine()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func runStubEngine() {
+	scanner := bufio.NewScanner(os.Stdin)
+	position := "startpos"
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+
+		switch parts[0] {
+		case "uci":
+			fmt.Println("id name Stub Engine")
+			fmt.Println("id author test")
+			fmt.Println("option name Hash type spin default 16 min 1 max 1024")
+			fmt.Println("option name UCI_Variant type combo default chess var chess var crazyhouse")
+			fmt.Println("option name Book File type string default <empty>")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("info string not ready yet")
+			fmt.Println("readyok")
+		case "position":
+			position = strings.Join(parts[1:], " ")
+		case "go":
+			{
+				mode := ""
+				if len(parts) > 1 {
+					mode = parts[1]
+				}
+
+				switch mode {
+				case "hang":
+					// NOTE: never answers, not even to stop
+				case "infinite":
+					{
+						for scanner.Scan() {
+							if scanner.Text() == "stop" {
+								break
+							}
+						}
+						fmt.Println("bestmove a2a3")
+					}
+				default:
+					{
+						fmt.Println("info string " + position)
+						fmt.Println("info depth 1 seldepth 2 multipv 1 score cp 12 nodes 20 nps 2000 time 10 pv e2e4")
+						fmt.Println("info depth 2 seldepth 4 multipv 1 score cp 30 lowerbound nodes 400 hashfull 5 tbhits 1 pv e2e4 e7e5")
+						fmt.Println("info currmove e2e4 currmovenumber 1")
+						fmt.Println("bestmove e2e4 ponder e7e5")
+					}
+				}
+			}
+		case "quit":
+			return
+		}
+	}
+}
+
+func startStub(t *testing.T) *Engine {
+	t.Setenv(stubEnv, "1")
+
+	e, err := Start(os.Args[0])
+	if err != nil {
+		t.Fatalf("Start: %s", err)
+	}
+
+	if err := e.Handshake(5 * time.Second); err != nil {
+		e.Kill()
+		t.Fatalf("Handshake: %s", err)
+	}
+
+	return e
+}
+
+func TestParseInfo(t *testing.T) {
+	cases := []struct {
+		line string
+		info Info
+	}{
+		{
+			line: "info depth 12 seldepth 18 multipv 2 score mate -3 upperbound nodes 123456 nps 1000000 hashfull 512 tbhits 7 time 250 pv d2d4 d7d5 c2c4",
+			info: Info{
+				Depth:    12,
+				SelDepth: 18,
+				MultiPV:  2,
+				Score:    Score{Mate: -3, IsMate: true, UpperBound: true},
+				HasScore: true,
+				Nodes:    123456,
+				NPS:      1000000,
+				HashFull: 512,
+				TBHits:   7,
+				Time:     250 * time.Millisecond,
+				PV:       []string{"d2d4", "d7d5", "c2c4"},
+			},
+		},
+		{
+			line: "info score cp -45 lowerbound depth 3",
+			info: Info{
+				Depth:    3,
+				Score:    Score{CP: -45, LowerBound: true},
+				HasScore: true,
+			},
+		},
+		{
+			line: "info string hello there world",
+			info: Info{String: "hello there world"},
+		},
+	}
+
+	for _, c := range cases {
+		info, err := ParseInfo(c.line)
+		if err != nil {
+			t.Errorf("Line: %s\nError: %s", c.line, err)
+			continue
+		}
+
+		if fmt.Sprint(info) != fmt.Sprint(c.info) {
+			t.Errorf("Line: %s\nExpected %+v\nfound %+v", c.line, c.info, info)
+		}
+	}
+
+	if _, err := ParseInfo("info score pawns 3"); err == nil {
+		t.Errorf("Expected an error for an unknown score type")
+	}
+}
+
+func TestEngine(t *testing.T) {
+	e := startStub(t)
+
+	if e.Name != "Stub Engine" || e.Author != "test" {
+		t.Errorf("Expected id Stub Engine by test found %s by %s", e.Name, e.Author)
+	}
+
+	if len(e.Options) != 3 {
+		t.Fatalf("Expected 3 options found %d", len(e.Options))
+	}
+
+	hash := e.Options[0]
+	if hash.Name != "Hash" || hash.Type != "spin" || hash.Default != "16" || hash.Min != 1 || hash.Max != 1024 {
+		t.Errorf("Unexpected Hash option %+v", hash)
+	}
+
+	variant := e.Options[1]
+	if variant.Name != "UCI_Variant" || strings.Join(variant.Vars, ",") != "chess,crazyhouse" {
+		t.Errorf("Unexpected UCI_Variant option %+v", variant)
+	}
+
+	if e.Options[2].Name != "Book File" || e.Options[2].Default != "<empty>" {
+		t.Errorf("Unexpected Book File option %+v", e.Options[2])
+	}
+
+	if err := e.SetOption("Hash", "64"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.IsReady(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Position("", []string{"e2e4", "e7e5"}); err != nil {
+		t.Fatal(err)
+	}
+
+	infos := make(chan Info, 16)
+	bestmove, err := e.Go("depth 2", infos)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	best, err := e.Wait(bestmove, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if best.Move != "e2e4" || best.Ponder != "e7e5" {
+		t.Errorf("Expected bestmove e2e4 ponder e7e5 found %s ponder %s", best.Move, best.Ponder)
+	}
+
+	if best.Info.Depth != 2 || best.Info.Score.CP != 30 || !best.Info.Score.LowerBound {
+		t.Errorf("Expected last scored info at depth 2 found %+v", best.Info)
+	}
+
+	// NOTE: every info is sent before the bestmove so they are all buffered by now
+	var received []Info
+	for len(infos) > 0 {
+		received = append(received, <-infos)
+	}
+
+	if len(received) != 4 || received[0].String != "startpos moves e2e4 e7e5" {
+		t.Errorf("Expected 4 infos starting with the position found %+v", received)
+	}
+
+	// NOTE: nobody reads an unbuffered channel, the infos are dropped and the bestmove still arrives
+	for _, c := range []chan Info{make(chan Info), infos} {
+		bestmove, err = e.Go("depth 2", c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if best, err := e.Wait(bestmove, 5*time.Second); err != nil || best.Move != "e2e4" {
+			t.Errorf("Expected bestmove e2e4 found %s (%v)", best.Move, err)
+		}
+	}
+
+	if len(infos) != 4 {
+		t.Errorf("Expected the reused infos channel to get 4 infos found %d", len(infos))
+	}
+
+	bestmove, err = e.Go("infinite", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.StopGrace = 5 * time.Second
+	best, err = e.Wait(bestmove, 50*time.Millisecond)
+	if err != nil || best.Move != "a2a3" {
+		t.Errorf("Expected stop to return bestmove a2a3 found %s (%v)", best.Move, err)
+	}
+
+	if err := e.Quit(5 * time.Second); err != nil {
+		t.Errorf("Quit: %s", err)
+	}
+	if err := e.Quit(50 * time.Millisecond); err != nil {
+		t.Errorf("Expected a second Quit to return the same exit status found %v", err)
+	}
+}
+
+func TestEngineHung(t *testing.T) {
+	e := startStub(t)
+	e.StopGrace = 50 * time.Millisecond
+
+	if err := e.IsReady(0); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected a spent deadline to time out found %v", err)
+	}
+
+	bestmove, err := e.Go("hang", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.Wait(bestmove, 50*time.Millisecond); err != ErrTimeout {
+		t.Errorf("Expected ErrTimeout found %v", err)
+	}
+
+	if _, ok := <-bestmove; ok {
+		t.Errorf("Expected bestmove channel to be closed after kill")
+	}
+}
@@ -0,0 +1,271 @@
+package uciclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Score struct {
+	// NOTE: Mate is only meaningful when IsMate is set, negative means the engine is getting mated
+	CP     int
+	Mate   int
+	IsMate bool
+
+	LowerBound bool
+	UpperBound bool
+}
+
+func (s Score) String() string {
+	var builder strings.Builder
+
+	if s.IsMate {
+		fmt.Fprintf(&builder, "mate %d", s.Mate)
+	} else {
+		fmt.Fprintf(&builder, "cp %d", s.CP)
+	}
+
+	if s.LowerBound {
+		builder.WriteString(" lowerbound")
+	}
+	if s.UpperBound {
+		builder.WriteString(" upperbound")
+	}
+
+	return builder.String()
+}
+
+type Info struct {
+	Depth          int
+	SelDepth       int
+	MultiPV        int
+	Score          Score
+	HasScore       bool
+	Time           time.Duration
+	Nodes          uint64
+	NPS            uint64
+	HashFull       int
+	TBHits         uint64
+	CurrMove       string
+	CurrMoveNumber int
+	PV             []string
+	String         string
+}
+
+// ParseInfo parses an "info ..." line, unknown tokens are skipped
+func ParseInfo(line string) (Info, error) {
+	parts := strings.Fields(line)
+	if len(parts) == 0 || parts[0] != "info" {
+		return Info{}, fmt.Errorf("Invalid info: expected info found %s", line)
+	}
+
+	info := Info{}
+
+	for i := 1; i < len(parts); i++ {
+		key := parts[i]
+
+		switch key {
+		case "pv":
+			{
+				info.PV = append([]string(nil), parts[i+1:]...)
+				return info, nil
+			}
+		case "string":
+			{
+				info.String = strings.Join(parts[i+1:], " ")
+				return info, nil
+			}
+		case "score":
+			{
+				if i+2 >= len(parts) {
+					return info, fmt.Errorf("Invalid info: %s expected score cp or score mate", line)
+				}
+
+				value, err := strconv.Atoi(parts[i+2])
+				if err != nil {
+					return info, fmt.Errorf("Invalid info: %s expected integer score found %s", line, parts[i+2])
+				}
+
+				switch parts[i+1] {
+				case "cp":
+					{
+						info.Score.CP = value
+					}
+				case "mate":
+					{
+						info.Score.Mate = value
+						info.Score.IsMate = true
+					}
+				default:
+					{
+						return info, fmt.Errorf("Invalid info: %s expected cp or mate found %s", line, parts[i+1])
+					}
+				}
+
+				info.HasScore = true
+				i += 2
+
+				for i+1 < len(parts) && (parts[i+1] == "lowerbound" || parts[i+1] == "upperbound") {
+					i++
+					if parts[i] == "lowerbound" {
+						info.Score.LowerBound = true
+					} else {
+						info.Score.UpperBound = true
+					}
+				}
+			}
+		case "currmove":
+			{
+				if i+1 >= len(parts) {
+					return info, fmt.Errorf("Invalid info: %s missing value for %s", line, key)
+				}
+
+				i++
+				info.CurrMove = parts[i]
+			}
+		case "depth", "seldepth", "multipv", "time", "nodes", "nps", "hashfull", "tbhits", "currmovenumber":
+			{
+				if i+1 >= len(parts) {
+					return info, fmt.Errorf("Invalid info: %s missing value for %s", line, key)
+				}
+
+				i++
+				value, err := strconv.ParseUint(parts[i], 10, 64)
+				if err != nil {
+					return info, fmt.Errorf("Invalid info: %s expected integer for %s found %s", line, key, parts[i])
+				}
+
+				switch key {
+				case "depth":
+					info.Depth = int(value)
+				case "seldepth":
+					info.SelDepth = int(value)
+				case "multipv":
+					info.MultiPV = int(value)
+				case "time":
+					info.Time = time.Duration(value) * time.Millisecond
+				case "nodes":
+					info.Nodes = value
+				case "nps":
+					info.NPS = value
+				case "hashfull":
+					info.HashFull = int(value)
+				case "tbhits":
+					info.TBHits = value
+				case "currmovenumber":
+					info.CurrMoveNumber = int(value)
+				}
+			}
+		}
+	}
+
+	return info, nil
+}
+
+type BestMove struct {
+	Move   string
+	Ponder string
+
+	// NOTE: the last info with a score sent before bestmove
+	Info Info
+}
+
+func parseBestMove(line string) (BestMove, error) {
+	parts := strings.Fields(line)
+	if len(parts) < 2 || parts[0] != "bestmove" {
+		return BestMove{}, fmt.Errorf("Invalid bestmove: %s", line)
+	}
+
+	best := BestMove{Move: parts[1]}
+	if len(parts) >= 4 && parts[2] == "ponder" {
+		best.Ponder = parts[3]
+	}
+
+	return best, nil
+}
+
+type Option struct {
+	Name    string
+	Type    string
+	Default string
+	Min     int
+	Max     int
+	Vars    []string
+}
+
+// parseOption parses an "option name ... type ..." line, the name and default may contain spaces
+func parseOption(line string) (Option, error) {
+	parts := strings.Fields(line)
+	if len(parts) < 5 || parts[0] != "option" || parts[1] != "name" {
+		return Option{}, fmt.Errorf("Invalid option: %s", line)
+	}
+
+	option := Option{}
+
+	key := ""
+	var value []string
+	flush := func() error {
+		joined := strings.Join(value, " ")
+
+		switch key {
+		case "name":
+			option.Name = joined
+		case "type":
+			option.Type = joined
+		case "default":
+			option.Default = joined
+		case "var":
+			option.Vars = append(option.Vars, joined)
+		case "min", "max":
+			{
+				n, err := strconv.Atoi(joined)
+				if err != nil {
+					return fmt.Errorf("Invalid option: %s expected integer for %s found %s", line, key, joined)
+				}
+
+				if key == "min" {
+					option.Min = n
+				} else {
+					option.Max = n
+				}
+			}
+		}
+
+		return nil
+	}
+
+	for _, part := range parts[1:] {
+		switch part {
+		case "name", "type", "default", "min", "max", "var":
+			{
+				// NOTE: keywords inside a name are kept, names end only at "type"
+				if key == "name" && part != "type" {
+					value = append(value, part)
+					continue
+				}
+
+				if err := flush(); err != nil {
+					return option, err
+				}
+
+				key = part
+				value = value[:0]
+			}
+		default:
+			{
+				value = append(value, part)
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return option, err
+	}
+
+	if option.Name == "" || option.Type == "" {
+		return option, fmt.Errorf("Invalid option: %s expected name and type", line)
+	}
+
+	return option, nil
+}
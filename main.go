@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -11,27 +12,127 @@ import (
 	"github.com/neet-007/chess_engine_go/internal/board"
 )
 
+const startPosFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+func startFEN(variant board.Variant) string {
+	switch variant {
+	case board.Crazyhouse:
+		{
+			return "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1"
+		}
+	case board.ThreeCheck:
+		{
+			return "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 3+3 0 1"
+		}
+	case board.Antichess:
+		{
+			return "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1"
+		}
+	case board.Horde:
+		{
+			return "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1"
+		}
+	case board.RacingKings:
+		{
+			return "8/8/8/8/8/8/krbnNBRK/qrbnNBRQ w - - 0 1"
+		}
+	default:
+		{
+			return startPosFEN
+		}
+	}
+}
+
 type Engine struct {
-	id     string
-	author string
-	board  *board.Board
+	id      string
+	author  string
+	board   *board.Board
+	debug   bool
+	out     io.Writer
+	options *Options
+	variant board.Variant
 }
 
 func NewEngine(id string, author string) *Engine {
-	return &Engine{
-		id:     id,
-		author: author,
-		board:  board.NewBoard(),
+	e := &Engine{
+		id:      id,
+		author:  author,
+		board:   board.NewBoard(),
+		out:     os.Stdout,
+		options: NewOptions(),
+	}
+
+	e.options.Add(NewComboOption("UCI_Variant", board.Standard.String(), board.VariantNames, func(o *Option) error {
+		variant, err := board.ParseVariant(o.Value)
+		if err != nil {
+			return err
+		}
+
+		e.variant = variant
+		return nil
+	}))
+
+	return e
+}
+
+// NOTE: info string is always valid UCI, use it for anything that is not a protocol reply
+func (e *Engine) infoString(format string, args ...any) {
+	fmt.Fprintf(e.out, "info string "+format+"\n", args...)
+}
+
+func (e *Engine) debugf(format string, args ...any) {
+	if e.debug {
+		e.infoString(format, args...)
 	}
 }
 
 func parseFEN(b *board.Board, fen string) (err error) {
 	parts := strings.Split(fen, " ")
+
+	// NOTE: three-check adds the checks each side has left before the clocks, like 3+3
+	if b.Variant == board.ThreeCheck && len(parts) == 7 {
+		if err := parseChecks(b, parts[4]); err != nil {
+			return fmt.Errorf("Invalid checks: %s %w", fen, err)
+		}
+
+		parts = append(parts[:4], parts[5:]...)
+	}
+
 	if len(parts) != 6 {
 		return fmt.Errorf("Invalid FEN: %s expected 6 parts found %d", fen, len(parts))
 	}
 
-	ranks := strings.Split(parts[0], "/")
+	placement := parts[0]
+	if start := strings.IndexByte(placement, '['); start >= 0 {
+		if b.Variant != board.Crazyhouse {
+			return fmt.Errorf("Invalid FEN: %s holdings are only allowed in crazyhouse", fen)
+		}
+
+		if !strings.HasSuffix(placement, "]") {
+			return fmt.Errorf("Invalid holdings: %s expected ] at the end of %s", fen, placement[start:])
+		}
+
+		for _, char := range placement[start+1 : len(placement)-1] {
+			piece, ok := board.CharToPiece[char]
+			if !ok || piece == board.White_king || piece == board.Black_king {
+				return fmt.Errorf("Invalid holdings: %s expected P, N, B, R or Q found %s", fen, string(char))
+			}
+
+			color := 0
+			if piece >= board.Black_pawn {
+				color = 1
+			}
+			if index := board.HandIndex(piece); b.Hand[color][index] >= board.HandLimits[index] {
+				return fmt.Errorf("Invalid holdings: %s more than %d %s in hand", fen, board.HandLimits[index], string(char))
+			}
+
+			b.AddToHand(piece)
+		}
+
+		placement = placement[:start]
+	}
+
+	ranks := strings.Split(placement, "/")
 	if len(ranks) != 8 {
 		return fmt.Errorf("Invalid FEN: %s expected 8 ranks found %d", fen, len(ranks))
 	}
@@ -39,15 +140,38 @@ func parseFEN(b *board.Board, fen string) (err error) {
 	for i, rank := range ranks {
 		i = 7 - i
 		total := 0
+		afterPiece := false
 		for _, char := range rank {
 			if unicode.IsDigit(char) {
 				count := int(char - '0')
 				total += count
+				afterPiece = false
 				continue
 			}
 
-			b.PutInSquare(uint8(total), uint8(i), board.CharToPiece[char])
+			// NOTE: crazyhouse marks promoted pieces with ~ after the piece
+			if char == '~' {
+				if b.Variant != board.Crazyhouse || !afterPiece {
+					return fmt.Errorf("Invalid FEN: %s unexpected ~", fen)
+				}
+
+				b.Promoted |= 1 << board.GetSquareIndex(uint8(total-1), uint8(i))
+				afterPiece = false
+				continue
+			}
+
+			piece, ok := board.CharToPiece[char]
+			if !ok {
+				return fmt.Errorf("Invalid FEN: %s expected a piece or digit found %s", fen, string(char))
+			}
+
+			if total >= 8 {
+				return fmt.Errorf("Invalid FEN: %s expected 8 squares in rank %d", fen, i+1)
+			}
+
+			b.PutInSquare(uint8(total), uint8(i), piece)
 			total++
+			afterPiece = true
 		}
 
 		if total != 8 {
@@ -72,9 +196,7 @@ func parseFEN(b *board.Board, fen string) (err error) {
 		}
 	}
 
-	if parts[2] == "-" {
-		fmt.Println("No castling rights")
-	} else {
+	if parts[2] != "-" {
 		if len(parts[2]) > 4 {
 			return fmt.Errorf("Invalid castling rights: %s expected 4 letters found %d", fen, len(parts[2]))
 		}
@@ -105,11 +227,11 @@ func parseFEN(b *board.Board, fen string) (err error) {
 		}
 	}
 
-	if parts[3] == "-" {
-		fmt.Println("No en passant square")
-	} else {
-		// TODO: mabye validate the square?
-		fmt.Printf("En passant square is %s\n", parts[3])
+	if parts[3] != "-" {
+		if len(parts[3]) != 2 {
+			return fmt.Errorf("Invalid en passant square: %s expected a1-h8 found %s", fen, parts[3])
+		}
+
 		file := parts[3][0] - 'a'
 		rank := parts[3][1] - '1'
 
@@ -124,14 +246,30 @@ func parseFEN(b *board.Board, fen string) (err error) {
 		return fmt.Errorf("Invalid half moves: %s expected integer found %s", fen, parts[4])
 	} else {
 		b.HalfMoves = halfMoves
-		fmt.Printf("Half moves is %d\n", halfMoves)
 	}
 
 	if fullMoves, err := strconv.Atoi(parts[5]); err != nil {
 		return fmt.Errorf("Invalid full moves: %s expected integer found %s", fen, parts[5])
 	} else {
 		b.FullMoves = fullMoves
-		fmt.Printf("Full moves is %d\n", fullMoves)
+	}
+
+	return nil
+}
+
+func parseChecks(b *board.Board, field string) error {
+	white, black, ok := strings.Cut(field, "+")
+	if !ok {
+		return fmt.Errorf("expected white+black found %s", field)
+	}
+
+	for color, remaining := range []string{white, black} {
+		count, err := strconv.Atoi(remaining)
+		if err != nil || count < 0 || count > 3 {
+			return fmt.Errorf("expected 0-3 checks left found %s", remaining)
+		}
+
+		b.Checks[color] = uint8(3 - count)
 	}
 
 	return nil
@@ -160,6 +298,9 @@ func serializeFEN(b *board.Board) string {
 					foundPiece = board.Piece(i)
 
 					builder.WriteString(foundPiece.String())
+					if b.Promoted&(1<<index) != 0 {
+						builder.WriteString("~")
+					}
 					break
 				}
 			}
@@ -178,6 +319,17 @@ func serializeFEN(b *board.Board) string {
 		}
 	}
 
+	if b.Variant == board.Crazyhouse {
+		builder.WriteString("[")
+		for color, offset := range []board.Piece{board.White_pawn, board.Black_pawn} {
+			// NOTE: queens first, the usual order for holdings
+			for _, piece := range []board.Piece{board.White_queen, board.White_rook, board.White_bishop, board.White_knight, board.White_pawn} {
+				builder.WriteString(strings.Repeat((piece + offset).String(), int(b.Hand[color][board.HandIndex(piece)])))
+			}
+		}
+		builder.WriteString("]")
+	}
+
 	if b.CurrentTurn == board.WhiteTurn {
 		builder.WriteString(" w")
 	} else {
@@ -216,6 +368,10 @@ func serializeFEN(b *board.Board) string {
 		builder.WriteString("-")
 	}
 
+	if b.Variant == board.ThreeCheck {
+		fmt.Fprintf(&builder, " %d+%d", 3-int(b.Checks[0]), 3-int(b.Checks[1]))
+	}
+
 	builder.WriteString(" ")
 	builder.WriteString(strconv.Itoa(b.HalfMoves))
 	builder.WriteString(" ")
@@ -224,104 +380,228 @@ func serializeFEN(b *board.Board) string {
 	return builder.String()
 }
 
-func readUCI(engine *Engine) {
-	scanner := bufio.NewScanner(os.Stdin)
+func parsePosition(engine *Engine, parts []string) error {
+	if len(parts) < 2 {
+		return fmt.Errorf("Invalid position: expected startpos or fen")
+	}
+
+	var fen string
+	rest := parts[2:]
+
+	switch parts[1] {
+	case "startpos":
+		{
+			fen = startFEN(engine.variant)
+		}
+	case "fen":
+		{
+			end := len(rest)
+			for i, part := range rest {
+				if part == "moves" {
+					end = i
+					break
+				}
+			}
+
+			fen = strings.Join(rest[:end], " ")
+			rest = rest[end:]
+		}
+	default:
+		{
+			return fmt.Errorf("Invalid position: expected startpos or fen found %s", parts[1])
+		}
+	}
+
+	b := board.NewBoard()
+	b.Variant = engine.variant
+	if err := parseFEN(b, fen); err != nil {
+		return err
+	}
+
+	if len(rest) > 0 && rest[0] == "moves" && len(rest) > 1 {
+		// TODO: apply the moves once the board can make moves
+		return fmt.Errorf("Invalid position: moves are not supported yet")
+	}
+
+	engine.board = b
+	return nil
+}
+
+func readUCI(engine *Engine, in io.Reader) {
+	scanner := bufio.NewScanner(in)
 	scanner.Split(bufio.ScanLines)
 
 	for scanner.Scan() {
 		command := scanner.Text()
-		fmt.Println(command)
 
-		parts := strings.Split(command, " ")
+		parts := strings.Fields(command)
+		if len(parts) == 0 {
+			continue
+		}
+
+		engine.debugf("received %s", command)
 
 		switch parts[0] {
 		case "uci":
 			{
-				fmt.Fprintf(os.Stdout, "id name %s\n", engine.id)
-				fmt.Fprintf(os.Stdout, "id author %s\n", engine.author)
+				fmt.Fprintf(engine.out, "id name %s\n", engine.id)
+				fmt.Fprintf(engine.out, "id author %s\n", engine.author)
+				engine.options.Print(engine.out)
+				fmt.Fprintf(engine.out, "uciok\n")
 			}
 		case "debug":
 			{
-				fmt.Fprintf(os.Stdout, "Uninmplemented command: %s\n", command)
+				if len(parts) < 2 || (parts[1] != "on" && parts[1] != "off") {
+					engine.debugf("expected debug on or debug off")
+					continue
+				}
+
+				engine.debug = parts[1] == "on"
+				engine.debugf("debug is on")
 			}
 		case "isready":
 			{
-				fmt.Fprintf(os.Stdout, "readyok\n")
+				fmt.Fprintf(engine.out, "readyok\n")
 			}
 		case "setoption":
 			{
-				name := parts[1]
-				value := parts[2]
+				name, value, err := parseSetOption(command)
+				if err != nil {
+					engine.infoString("%s", err)
+					continue
+				}
+
+				if err := engine.options.Set(name, value); err != nil {
+					engine.infoString("%s", err)
+					continue
+				}
 
-				fmt.Fprintf(os.Stdout, "option name %s value %s\n", name, value)
+				engine.debugf("option %s set to %s", name, engine.options.Get(name).Value)
 			}
 		case "ucinewgame":
 			{
-				fmt.Fprintf(os.Stdout, "Unimplemented command: %s\n", command)
+				engine.debugf("unimplemented command: %s", command)
 			}
 		case "go":
 			{
-				fmt.Fprintf(os.Stdout, "Unimplemented command: %s\n", command)
+				engine.debugf("unimplemented command: %s", command)
 			}
 		case "ponderhit":
 			{
-				fmt.Fprintf(os.Stdout, "Unimplemented command: %s\n", command)
+				engine.debugf("unimplemented command: %s", command)
 			}
 		case "position":
 			{
-				fmt.Fprintf(os.Stdout, "Unimplemented command: %s\n", command)
+				if err := parsePosition(engine, parts); err != nil {
+					engine.infoString("%s", err)
+					continue
+				}
+
+				engine.debugf("position fen %s", serializeFEN(engine.board))
+				if err := engine.board.Validate(); err != nil {
+					engine.debugf("%s", err)
+				}
 			}
 		case "quit":
 			{
-				fmt.Fprintf(os.Stdout, "Unimplemented command: %s\n", command)
+				return
 			}
 		case "register":
 			{
-				fmt.Fprintf(os.Stdout, "Unimplemented command: %s\n", command)
+				engine.debugf("unimplemented command: %s", command)
 			}
 		case "stop":
 			{
-				fmt.Fprintf(os.Stdout, "Unimplemented command: %s\n", command)
+				engine.debugf("unimplemented command: %s", command)
 			}
 		default:
 			{
-				fmt.Fprintf(os.Stdout, "Unknown command: %s\n", command)
+				engine.debugf("unknown command: %s", command)
 			}
 		}
 	}
 }
 
-func main() {
-	scanner := bufio.NewScanner(os.Stdin)
+// readFEN round trips FENs through parseFEN and serializeFEN, it is used when
+// the first line is not a protocol command
+func readFEN(engine *Engine, in io.Reader) {
+	scanner := bufio.NewScanner(in)
 	scanner.Split(bufio.ScanLines)
 
-	engine := Engine{
-		id:     "chess_engine",
-		author: "Moayed",
-		board: &board.Board{
-			CurrentTurn: board.WhiteTurn,
-		},
-	}
-	_ = engine
-
-	fmt.Printf("> ")
+	fmt.Fprintf(engine.out, "> ")
 	for scanner.Scan() {
 		line := scanner.Text()
-		fmt.Println(line)
+		fmt.Fprintln(engine.out, line)
 
+		engine.board = board.NewBoard()
 		if err := parseFEN(engine.board, line); err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(engine.out, err)
 			continue
 		}
 
 		serialized := serializeFEN(engine.board)
 		if serialized == "" {
-			fmt.Println("Invalid FEN")
+			fmt.Fprintln(engine.out, "Invalid FEN")
 		} else {
-			fmt.Println(serialized)
+			fmt.Fprintln(engine.out, serialized)
 			if serialized != line {
-				fmt.Println("FEN is not equal to serialized FEN")
+				fmt.Fprintln(engine.out, "FEN is not equal to serialized FEN")
 			}
 		}
 	}
 }
+
+// run picks the protocol from the first command, blank lines before it are
+// skipped and the command is replayed to the chosen front end
+func run(engine *Engine, in io.Reader) {
+	reader := bufio.NewReader(in)
+
+	first, err := reader.ReadString('\n')
+	for strings.TrimSpace(first) == "" && err == nil {
+		first, err = reader.ReadString('\n')
+	}
+	in = io.MultiReader(strings.NewReader(first), reader)
+
+	protocol := ""
+	if fields := strings.Fields(first); len(fields) > 0 {
+		protocol = fields[0]
+	}
+
+	switch protocol {
+	case "uci":
+		{
+			readUCI(engine, in)
+		}
+	case "xboard":
+		{
+			readXBoard(engine, in)
+		}
+	default:
+		{
+			readFEN(engine, in)
+		}
+	}
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "convert":
+			{
+				if err := runConvert(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			}
+		default:
+			{
+				fmt.Fprintf(os.Stderr, "Unknown subcommand: %s\n", os.Args[1])
+				os.Exit(2)
+			}
+		}
+
+		return
+	}
+
+	run(NewEngine("chess_engine", "Moayed"), os.Stdin)
+}
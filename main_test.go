@@ -1,8 +1,12 @@
 package main
 
 import (
-	"github.com/neet-007/chess_engine_go/internal/board"
+	"bytes"
+	"math/bits"
+	"strings"
 	"testing"
+
+	"github.com/neet-007/chess_engine_go/internal/board"
 )
 
 func TestBoard(t *testing.T) {
@@ -119,3 +123,952 @@ func TestMoveEncode(t *testing.T) {
 		}
 	}
 }
+
+func TestMoveFlags(t *testing.T) {
+	cases := []struct {
+		flags     uint16
+		capture   bool
+		promotion bool
+	}{
+		{board.QuietMoveFlag, false, false},
+		{board.DoublePushFlag, false, false},
+		{board.KingCastleFlag, false, false},
+		{board.QueenCastleFlag, false, false},
+		{board.CaptureFlag, true, false},
+		{board.EpCaptureFlag, true, false},
+		{board.KnightPromotionFlag, false, true},
+		{board.QueenPromotionFlag, false, true},
+		{board.BishopPromoCaptureFlag, true, true},
+		{board.QueenPromoCaptureFlag, true, true},
+	}
+
+	for _, c := range cases {
+		m := board.NewMove(board.E2, board.E4, c.flags)
+		if m.IsCapture() != c.capture {
+			t.Errorf("in Move %b Expected capture %t found %t", m, c.capture, m.IsCapture())
+		}
+
+		if m.IsPromotion() != c.promotion {
+			t.Errorf("in Move %b Expected promotion %t found %t", m, c.promotion, m.IsPromotion())
+		}
+	}
+}
+
+func TestUCIDebug(t *testing.T) {
+	input := strings.Join([]string{
+		"position startpos",
+		"isready",
+		"debug on",
+		"position fen " + "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"position fen 8/8/8 w - - 0 1",
+		"position fen 8/8/8/8/8/8/8/8 w - e 0 1",
+		"position fen ppppppppp/8/8/8/8/8/8/8 w - - 0 1",
+		"position fen 8/8/8/8/8/8/8/7x w - - 0 1",
+		"debug off",
+		"go",
+		"isready",
+		"quit",
+		"isready",
+	}, "\n")
+
+	var out bytes.Buffer
+	engine := NewEngine("chess_engine", "test")
+	engine.out = &out
+
+	readUCI(engine, strings.NewReader(input))
+
+	if count := strings.Count(out.String(), "readyok\n"); count != 2 {
+		t.Errorf("Expected quit to stop reading commands found %d readyok", count)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	for _, line := range lines {
+		if line != "readyok" && !strings.HasPrefix(line, "info string ") {
+			t.Errorf("Expected only protocol output found %q", line)
+		}
+	}
+
+	if lines[0] != "readyok" {
+		t.Errorf("Expected no output before debug on found %q", lines[0])
+	}
+
+	if lines[len(lines)-2] != "info string received debug off" {
+		t.Errorf("Expected no diagnostics after debug off found %q", lines[len(lines)-2])
+	}
+
+	expected := []string{
+		"info string received position fen r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"info string position fen r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"info string Invalid FEN: 8/8/8 w - - 0 1 expected 8 ranks found 3",
+		"info string Invalid en passant square: 8/8/8/8/8/8/8/8 w - e 0 1 expected a1-h8 found e",
+		"info string Invalid FEN: ppppppppp/8/8/8/8/8/8/8 w - - 0 1 expected 8 squares in rank 8",
+		"info string Invalid FEN: 8/8/8/8/8/8/8/7x w - - 0 1 expected a piece or digit found x",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out.String(), e+"\n") {
+			t.Errorf("Expected %q in output\n%s", e, out.String())
+		}
+	}
+}
+
+func TestUCIOptions(t *testing.T) {
+	var out bytes.Buffer
+	engine := NewEngine("chess_engine", "test")
+	engine.out = &out
+
+	changed := map[string]string{}
+	record := func(o *Option) error {
+		changed[o.Name] = o.Value
+		return nil
+	}
+
+	engine.options.Add(NewSpinOption("Hash", 16, 1, 1024, record))
+	engine.options.Add(NewCheckOption("Ponder", false, record))
+	engine.options.Add(NewComboOption("Style", "Normal", []string{"Solid", "Normal", "Risky"}, record))
+	engine.options.Add(NewStringOption("Book File", "", record))
+	engine.options.Add(NewButtonOption("Clear Hash", record))
+
+	input := strings.Join([]string{
+		"uci",
+		"setoption name Hash value 64",
+		"setoption name hash value 4096",
+		"setoption name Ponder value true",
+		"setoption name Style value risky",
+		"setoption name Style value Wild",
+		"setoption name Book File value my book.bin",
+		"setoption  name Book  File value  my  book.bin ",
+		"setoption name Clear Hash",
+		"setoption name Missing value 1",
+	}, "\n")
+
+	readUCI(engine, strings.NewReader(input))
+
+	expected := strings.Join([]string{
+		"id name chess_engine",
+		"id author test",
+		"option name UCI_Variant type combo default chess var chess var crazyhouse var 3check var kingofthehill var racingkings var atomic var antichess var horde",
+		"option name Hash type spin default 16 min 1 max 1024",
+		"option name Ponder type check default false",
+		"option name Style type combo default Normal var Solid var Normal var Risky",
+		"option name Book File type string default <empty>",
+		"option name Clear Hash type button",
+		"uciok",
+		"info string Invalid value for Hash: expected 1-1024 found 4096",
+		"info string Invalid value for Style: expected one of Solid, Normal, Risky found Wild",
+		"info string Unknown option: Missing",
+	}, "\n") + "\n"
+
+	if out.String() != expected {
+		t.Errorf("Expected output\n%s\nfound\n%s", expected, out.String())
+	}
+
+	values := map[string]string{
+		"Hash":       "64",
+		"Ponder":     "true",
+		"Style":      "Risky",
+		"Book File":  "my  book.bin",
+		"Clear Hash": "",
+	}
+	for name, value := range values {
+		if v, ok := changed[name]; !ok || v != value {
+			t.Errorf("Option %s: expected callback with %q found %q (called %t)", name, value, v, ok)
+		}
+	}
+
+	if engine.options.Get("HASH").Int() != 64 || !engine.options.Get("ponder").Bool() {
+		t.Errorf("Expected typed values Hash 64 and Ponder true found %d and %t", engine.options.Get("Hash").Int(), engine.options.Get("Ponder").Bool())
+	}
+}
+
+func TestRunSkipsBlankLines(t *testing.T) {
+	var out bytes.Buffer
+	engine := NewEngine("chess_engine", "test")
+	engine.out = &out
+
+	run(engine, strings.NewReader("\n  \nuci\nisready\nquit\n"))
+
+	if !strings.Contains(out.String(), "uciok\n") || !strings.HasSuffix(out.String(), "readyok\n") {
+		t.Errorf("Expected blank lines before uci to be skipped found %q", out.String())
+	}
+}
+
+func TestXBoard(t *testing.T) {
+	input := strings.Join([]string{
+		"xboard",
+		"protover 2",
+		"accepted setboard",
+		"new",
+		"level 40 2:30 0.5",
+		"level 40 x 0",
+		"st 5",
+		"sd 0",
+		"time 30000",
+		"otim 29000",
+		"post",
+		"setboard r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"setboard 8/8 w - - 0 1",
+		"undo",
+		"analyze",
+		"exit",
+		"ping 7",
+		"foo",
+		"quit",
+		"ping 8",
+	}, "\n")
+
+	var out bytes.Buffer
+	engine := NewEngine("chess_engine", "test")
+	engine.out = &out
+
+	run(engine, strings.NewReader(input))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if lines[0] != "feature done=0" || lines[1] != `feature myname="chess_engine"` {
+		t.Errorf("Expected feature negotiation to start output found %q", lines[:2])
+	}
+
+	if !strings.Contains(out.String(), "feature analyze=0\n") {
+		t.Errorf("Expected analyze to be turned off until there is a search")
+	}
+
+	expected := []string{
+		"feature done=1",
+		"Error (Invalid level base: expected minutes or minutes:seconds found x): level 40 x 0",
+		"Error (invalid depth): sd 0",
+		"tellusererror Illegal position",
+		"Error (no moves to undo): undo",
+		"Error (search is not supported yet): analyze",
+		"Error (not analyzing): exit",
+		"pong 7",
+		"Error (unknown command): foo",
+	}
+	rest := lines[len(lines)-len(expected):]
+	for i := range expected {
+		if rest[i] != expected[i] {
+			t.Errorf("Expected %q found %q", expected[i], rest[i])
+		}
+	}
+
+	if serialized := serializeFEN(engine.board); serialized != "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1" {
+		t.Errorf("Expected setboard to load the position found %s", serialized)
+	}
+
+	if base, err := parseLevelBase("2:30"); err != nil || base.Seconds() != 150 {
+		t.Errorf("Expected level base 2:30 to be 150s found %s (%v)", base, err)
+	}
+}
+
+func TestPackedPosition(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r1bk3r/p2pBpNp/n5p1/1ppNP2P/6P1/3P4/P1P1K3/q5b1 b - - 0 1",
+		"rnbqkbnr/pppppp1p/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"8/8/8/8/8/8/8/8 w - - 0 1",
+		"8/P7/8/1k6/8/8/5K2/8 w - - 57 300",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w Kq - 3 1",
+	}
+
+	for i, fen := range fens {
+		b := board.NewBoard()
+		if err := parseFEN(b, fen); err != nil {
+			t.Fatalf("FEN: %s\nError: %s", fen, err)
+		}
+
+		score := int16(-200 + 100*i)
+		p, err := b.Pack(score, board.Draw)
+		if err != nil {
+			t.Errorf("FEN: %s\nError: %s", fen, err)
+			continue
+		}
+
+		unpacked, err := p.Unpack()
+		if err != nil {
+			t.Errorf("FEN: %s\nError: %s", fen, err)
+			continue
+		}
+
+		if serialized := serializeFEN(unpacked); serialized != fen {
+			t.Errorf("FEN: %s\nUnpacked: %s", fen, serialized)
+		}
+
+		if unpacked.Mailbox != b.Mailbox || unpacked.Hash() != b.Hash() {
+			t.Errorf("FEN: %s\nExpected unpacked mailbox and hash to match", fen)
+		}
+
+		if p.Score() != score || p.Result() != board.Draw {
+			t.Errorf("FEN: %s\nExpected score %d result 0.5 found %d %s", fen, score, p.Score(), p.Result())
+		}
+	}
+
+	// NOTE: a corrupt record with more than 32 pieces would read past the piece codes
+	for _, occupied := range []uint64{0x1FFFFFFFF, ^uint64(0)} {
+		var p board.PackedPosition
+		for i := range 8 {
+			p[i] = uint8(occupied >> (8 * i))
+		}
+
+		if _, err := p.Unpack(); err == nil {
+			t.Errorf("Expected occupancy %x to be rejected", occupied)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	input := strings.Join([]string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 | 15 | 0.5",
+		"rnbqkbnr/pppppp1p/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1 | -30 | 1.0",
+		"",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 4 9 | 20 | 0.0",
+		"8/P7/8/1k6/8/8/5K2/8 w - - 0 1 | 900 | 1.0",
+	}, "\n")
+
+	var packed bytes.Buffer
+	count, err := convert(strings.NewReader(input), &packed, convertOptions{from: "text", to: "packed", dedup: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 3 || packed.Len() != 3*board.PackedSize {
+		t.Fatalf("Expected 3 positions after dedup found %d (%d bytes)", count, packed.Len())
+	}
+
+	var text bytes.Buffer
+	if _, err := convert(&packed, &text, convertOptions{from: "packed", to: "text"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 | 15 | 0.5",
+		"rnbqkbnr/pppppp1p/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1 | -30 | 1.0",
+		"8/P7/8/1k6/8/8/5K2/8 w - - 0 1 | 900 | 1.0",
+	}, "\n") + "\n"
+	if text.String() != expected {
+		t.Errorf("Expected\n%s\nfound\n%s", expected, text.String())
+	}
+
+	packed.Reset()
+	count, err = convert(strings.NewReader(input+"\n8/8/8/8/8/8/8/8 w - - 0 1 | 1 | 2.0"), &packed, convertOptions{from: "text", to: "packed"})
+	if err == nil {
+		t.Errorf("Expected an error for an invalid result")
+	}
+	if count != 4 || packed.Len() != 4*board.PackedSize {
+		t.Errorf("Expected the 4 positions before the error to be flushed found %d (%d bytes)", count, packed.Len())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r1bk3r/p2pBpNp/n5p1/1ppNP2P/6P1/3P4/P1P1K3/q5b1 b - - 0 1",
+		"rnbqkbnr/pppppp1p/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	}
+
+	for _, fen := range valid {
+		b := board.NewBoard()
+		if err := parseFEN(b, fen); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := b.Validate(); err != nil {
+			t.Errorf("FEN: %s\nError: %s", fen, err)
+		}
+	}
+
+	cases := []struct {
+		name    string
+		fen     string
+		corrupt func(b *board.Board)
+	}{
+		{"No kings", "8/8/8/8/8/8/8/8 w - - 0 1", func(b *board.Board) {}},
+		{"Two white kings", "4k3/8/8/8/8/8/8/K3K3 w - - 0 1", func(b *board.Board) {}},
+		{"Castling without rook", "4k3/8/8/8/8/8/8/4K3 w K - 0 1", func(b *board.Board) {}},
+		{"Castling with moved king", "r3k2r/8/8/8/8/8/8/R4K1R w Q - 0 1", func(b *board.Board) {}},
+		{"En passant wrong rank", "4k3/8/8/8/4P3/8/8/4K3 w - e3 0 1", func(b *board.Board) {}},
+		{"En passant without pawn", "4k3/8/8/8/8/8/8/4K3 b - e3 0 1", func(b *board.Board) {}},
+		{"Overlapping pieces", startPosFEN, func(b *board.Board) {
+			b.Bitboards[board.White_queen] |= 1 << board.E1
+		}},
+		{"Stale white_all", startPosFEN, func(b *board.Board) {
+			b.Bitboards[board.White_all] &^= 1 << board.A2
+		}},
+		{"Stale empty", startPosFEN, func(b *board.Board) {
+			b.Empty |= 1 << board.A2
+		}},
+		{"Stale mailbox", startPosFEN, func(b *board.Board) {
+			b.Mailbox[board.D1] = uint8(board.White_king)
+		}},
+	}
+
+	for _, c := range cases {
+		b := board.NewBoard()
+		if err := parseFEN(b, c.fen); err != nil {
+			t.Fatal(err)
+		}
+
+		c.corrupt(b)
+		if err := b.Validate(); err == nil {
+			t.Errorf("%s: expected an error for %s", c.name, c.fen)
+		}
+	}
+}
+
+func TestParseUCIMove(t *testing.T) {
+	cases := []struct {
+		fen   string
+		move  string
+		flags uint16
+	}{
+		{startPosFEN, "e2e4", board.DoublePushFlag},
+		{startPosFEN, "g1f3", board.QuietMoveFlag},
+		{"rnbqkbnr/pppppp1p/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", "h7h6", board.QuietMoveFlag},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", "e1g1", board.KingCastleFlag},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", "e1c1", board.QueenCastleFlag},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R b KQkq - 0 1", "e8c8", board.QueenCastleFlag},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", "f3f6", board.CaptureFlag},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", "e5f7", board.CaptureFlag},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "e5f6", board.EpCaptureFlag},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "e5d6", 0xFF},
+		{"2n5/1P6/8/1k6/8/8/5K2/8 w - - 0 1", "b7b8q", board.QueenPromotionFlag},
+		{"2n5/1P6/8/1k6/8/8/5K2/8 w - - 0 1", "b7c8n", board.KnightPromoCaptureFlag},
+		{"2n5/1P6/8/1k6/8/8/5K2/8 w - - 0 1", "b7b8", 0xFF},
+		{"2n5/1P6/8/1k6/8/8/5K2/8 w - - 0 1", "b7b8k", 0xFF},
+		{"8/8/8/1k6/8/8/5K2/8 w - - 0 1", "f2f4", 0xFF},
+		{startPosFEN, "e2e5", 0xFF},
+		{startPosFEN, "f1c4", 0xFF},
+		{startPosFEN, "e1g1", 0xFF},
+		{startPosFEN, "e7e5", 0xFF},
+		{startPosFEN, "a1a2", 0xFF},
+		{startPosFEN, "e2e9", 0xFF},
+	}
+
+	for _, c := range cases {
+		b := board.NewBoard()
+		if err := parseFEN(b, c.fen); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := board.ParseUCIMove(b, c.move)
+		if c.flags == 0xFF {
+			if err == nil {
+				t.Errorf("FEN: %s\nMove %s: expected an error found %b", c.fen, c.move, m)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("FEN: %s\nMove %s: %s", c.fen, c.move, err)
+			continue
+		}
+
+		if m.Flags() != int(c.flags) || m.UCI() != c.move {
+			t.Errorf("FEN: %s\nMove %s: expected flags %b found %s with %b", c.fen, c.move, c.flags, m.UCI(), m.Flags())
+		}
+	}
+
+	if board.NullMove.UCI() != "0000" {
+		t.Errorf("Expected null move 0000 found %s", board.NullMove.UCI())
+	}
+
+	var list board.MoveList
+	list.Add(board.NewMove(board.E2, board.E4, board.DoublePushFlag))
+	list.Add(board.NewMove(board.G7, board.G8, board.RookPromotionFlag))
+	if list.Len() != 2 || list.Slice()[1].UCI() != "g7g8r" {
+		t.Errorf("Unexpected move list %v", list.Slice())
+	}
+	list.Clear()
+	if list.Len() != 0 {
+		t.Errorf("Expected an empty move list after Clear")
+	}
+}
+
+// assertTransformInvariant checks that measure, which scores a position from the
+// side to move's point of view like an evaluation or a perft count, is unchanged
+// by ColorFlip and by MirrorHorizontal when the position has no castling rights
+func assertTransformInvariant(t *testing.T, fen string, name string, measure func(b *board.Board) int) {
+	t.Helper()
+
+	original := board.NewBoard()
+	if err := parseFEN(original, fen); err != nil {
+		t.Fatal(err)
+	}
+	expected := measure(original)
+
+	flipped := board.NewBoard()
+	parseFEN(flipped, fen)
+	flipped.ColorFlip()
+	if found := measure(flipped); found != expected {
+		t.Errorf("FEN: %s\n%s after ColorFlip: expected %d found %d", fen, name, expected, found)
+	}
+
+	if original.Validate() == nil {
+		if err := flipped.Validate(); err != nil {
+			t.Errorf("FEN: %s\nColorFlip made an invalid board: %s", fen, err)
+		}
+	}
+
+	flipped.ColorFlip()
+	if serialized := serializeFEN(flipped); serialized != fen {
+		t.Errorf("FEN: %s\nExpected ColorFlip twice to give the same FEN found %s", fen, serialized)
+	}
+
+	mirrored := board.NewBoard()
+	parseFEN(mirrored, fen)
+	if err := mirrored.MirrorHorizontal(); err != nil {
+		return
+	}
+
+	if found := measure(mirrored); found != expected {
+		t.Errorf("FEN: %s\n%s after MirrorHorizontal: expected %d found %d", fen, name, expected, found)
+	}
+
+	mirrored.MirrorHorizontal()
+	if serialized := serializeFEN(mirrored); serialized != fen {
+		t.Errorf("FEN: %s\nExpected MirrorHorizontal twice to give the same FEN found %s", fen, serialized)
+	}
+}
+
+func materialBalance(b *board.Board) int {
+	values := [6]int{100, 300, 300, 500, 900, 0}
+
+	score := 0
+	for piece := range 6 {
+		score += values[piece] * bits.OnesCount64(b.Bitboards[piece])
+		score -= values[piece] * bits.OnesCount64(b.Bitboards[piece+6])
+	}
+
+	if b.CurrentTurn == board.BlackTurn {
+		return -score
+	}
+	return score
+}
+
+// NOTE: stands in for perft 1 until there is a move generator
+func countParsedMoves(b *board.Board) int {
+	count := 0
+	for from := range board.Square(64) {
+		for to := range board.Square(64) {
+			for _, suffix := range []string{"", "n", "b", "r", "q"} {
+				if _, err := board.ParseUCIMove(b, from.String()+to.String()+suffix); err == nil {
+					count++
+				}
+			}
+		}
+	}
+
+	return count
+}
+
+func TestTransforms(t *testing.T) {
+	fens := []string{
+		startPosFEN,
+		"r1bk3r/p2pBpNp/n5p1/1ppNP2P/6P1/3P4/P1P1K3/q5b1 b - - 0 1",
+		"rnbqkbnr/pppppp1p/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"2n5/1P6/8/1k6/8/8/5K2/8 w - - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w Kq - 0 1",
+		"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1",
+	}
+
+	for _, fen := range fens {
+		assertTransformInvariant(t, fen, "material", materialBalance)
+		assertTransformInvariant(t, fen, "parsed moves", countParsedMoves)
+	}
+
+	b := board.NewBoard()
+	parseFEN(b, "rnbqkbnr/pppppp1p/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	b.ColorFlip()
+	if serialized := serializeFEN(b); serialized != "rnbqkbnr/pppp1ppp/8/4p3/8/8/PPPPPP1P/RNBQKBNR w KQkq e6 0 1" {
+		t.Errorf("Unexpected ColorFlip %s", serialized)
+	}
+}
+
+func TestCrazyhouse(t *testing.T) {
+	var out bytes.Buffer
+	engine := NewEngine("chess_engine", "test")
+	engine.out = &out
+
+	fen := "r1bqk2r/pppp1ppp/2n2n2/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQK2R[Pp] w KQkq - 0 5"
+	readUCI(engine, strings.NewReader("position fen "+fen))
+	if out.String() == "" {
+		t.Errorf("Expected holdings to be rejected outside crazyhouse")
+	}
+
+	out.Reset()
+	readUCI(engine, strings.NewReader("setoption name UCI_Variant value crazyhouse\nposition fen "+fen))
+	if out.String() != "" {
+		t.Fatalf("Expected no output found %s", out.String())
+	}
+
+	if engine.variant != board.Crazyhouse || serializeFEN(engine.board) != fen {
+		t.Errorf("Expected %s found %s in %s", fen, serializeFEN(engine.board), engine.variant)
+	}
+
+	promoted := "4k3/8/8/8/8/8/8/Q~3K3[QRRBNPPnp] b - - 0 30"
+	b := board.NewBoard()
+	b.Variant = board.Crazyhouse
+	if err := parseFEN(b, promoted); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bad := range []string{"4k3/8/8/8/8/8/8/3~K3[] w - - 0 1", "4k3/8/8/8/8/8/8/Q~~3K3[] w - - 0 1"} {
+		if err := parseFEN(board.NewBoard(), bad); err == nil {
+			t.Errorf("Expected %s to be rejected", bad)
+		}
+
+		c := board.NewBoard()
+		c.Variant = board.Crazyhouse
+		if err := parseFEN(c, bad); err == nil {
+			t.Errorf("Expected ~ without a piece before it to be rejected in %s", bad)
+		}
+	}
+
+	overflow := board.NewBoard()
+	overflow.Variant = board.Crazyhouse
+	if err := parseFEN(overflow, "4k3/8/8/8/8/8/8/4K3["+strings.Repeat("P", 300)+"] w - - 0 1"); err == nil {
+		t.Errorf("Expected 300 pawns in hand to be rejected found %d", overflow.Hand[0][board.HandIndex(board.White_pawn)])
+	}
+
+	overflow = board.NewBoard()
+	overflow.Variant = board.Crazyhouse
+	if err := parseFEN(overflow, "4k3/8/8/8/8/8/8/4K3[QQ] w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	overflow.AddToHand(board.White_queen)
+	if overflow.Validate() == nil {
+		t.Errorf("Expected 3 queens in hand to be invalid")
+	}
+
+	if got := serializeFEN(b); got != promoted {
+		t.Errorf("Expected %s found %s", promoted, got)
+	}
+	if err := b.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	hash := b.Hash()
+	b.Promoted = 0
+	if b.Hash() == hash {
+		t.Errorf("Expected promoted pieces to change the hash")
+	}
+
+	b.Promoted = 1 << board.A1
+	b.CaptureToHand(uint8(board.A1))
+	if b.Hand[1][board.HandIndex(board.White_pawn)] != 2 || b.Promoted != 0 {
+		t.Errorf("Expected the promoted queen to go to black's hand as a pawn found %v", b.Hand)
+	}
+
+	cases := []struct {
+		move  string
+		valid bool
+	}{
+		{"N@e4", true},
+		{"P@e5", true},
+		{"P@e1", false},
+		{"Q@e4", false},
+		{"N@e8", false},
+		{"K@e4", false},
+	}
+
+	for _, c := range cases {
+		m, err := board.ParseUCIMove(b, c.move)
+		if !c.valid {
+			if err == nil {
+				t.Errorf("Move %s: expected an error found %s", c.move, m)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Move %s: %s", c.move, err)
+			continue
+		}
+
+		if !m.IsDrop() || m.IsCapture() || m.UCI() != c.move {
+			t.Errorf("Move %s: expected a drop found %s", c.move, m)
+		}
+	}
+
+	if _, err := b.Pack(0, board.Draw); err == nil {
+		t.Errorf("Expected crazyhouse positions to be rejected by Pack")
+	}
+}
+
+func TestVariantRules(t *testing.T) {
+	results := []struct {
+		variant board.Variant
+		fen     string
+		result  board.GameResult
+		over    bool
+	}{
+		{board.ThreeCheck, "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 1+3 0 2", board.Draw, false},
+		{board.ThreeCheck, "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 3+0 0 2", board.BlackWin, true},
+		{board.KingOfTheHill, "4k3/8/8/8/3K4/8/8/8 b - - 0 1", board.WhiteWin, true},
+		{board.KingOfTheHill, "8/8/8/4k3/8/8/8/4K3 w - - 0 1", board.BlackWin, true},
+		{board.KingOfTheHill, "4k3/8/8/8/8/8/8/4K3 w - - 0 1", board.Draw, false},
+		{board.RacingKings, "K7/8/k7/8/8/8/8/8 b - - 0 1", board.WhiteWin, true},
+		{board.RacingKings, "K7/6k1/8/8/8/8/8/8 b - - 0 1", board.Draw, false},
+		{board.RacingKings, "K7/6k1/8/8/8/8/8/8 w - - 0 1", board.WhiteWin, true},
+		{board.RacingKings, "K5k1/8/8/8/8/8/8/8 w - - 0 1", board.Draw, true},
+		{board.RacingKings, "6k1/8/K7/8/8/8/8/8 w - - 0 1", board.BlackWin, true},
+		{board.RacingKings, "K7/7k/8/8/8/8/8/B5R1 b - - 0 1", board.WhiteWin, true},
+	}
+
+	for _, c := range results {
+		b := board.NewBoard()
+		b.Variant = c.variant
+		if err := parseFEN(b, c.fen); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := b.Validate(); err != nil {
+			t.Errorf("%s %s: %s", c.variant, c.fen, err)
+		}
+		if got := serializeFEN(b); got != c.fen {
+			t.Errorf("%s: expected FEN %s found %s", c.variant, c.fen, got)
+		}
+
+		result, over := b.Result()
+		if over != c.over || (over && result != c.result) {
+			t.Errorf("%s %s: expected %s (%t) found %s (%t)", c.variant, c.fen, c.result, c.over, result, over)
+		}
+	}
+
+	// NOTE: position fen doesn't validate, a missing black king must not panic
+	kingless := board.NewBoard()
+	kingless.Variant = board.RacingKings
+	if err := parseFEN(kingless, "K7/8/8/8/8/8/8/8 b - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if result, over := kingless.Result(); !over || result != board.WhiteWin {
+		t.Errorf("Expected white to win without a black king found %s (%t)", result, over)
+	}
+
+	checks := []struct {
+		fen    string
+		move   string
+		checks bool
+	}{
+		{"4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", "a1a8", true},
+		{"4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", "a1a7", false},
+		{"4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", "e1c1", false},
+		{"3k4/8/8/8/8/8/8/R3K3 w Q - 0 1", "e1c1", true},
+		{"4k3/8/8/8/8/8/4N3/4R1K1 w - - 0 1", "e2c3", true},
+		{"4k3/8/8/8/8/8/4N3/4R1K1 w - - 0 1", "g1h1", false},
+		{"8/8/8/R1pP3k/8/8/8/4K3 w - c6 0 1", "d5c6", true},
+		{"8/1P2k3/8/8/8/8/8/4K3 w - - 0 1", "b7b8n", false},
+		{"8/1P6/3k4/8/8/8/8/4K3 w - - 0 1", "b7b8q", true},
+	}
+
+	for _, c := range checks {
+		b := board.NewBoard()
+		if err := parseFEN(b, c.fen); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := board.ParseUCIMove(b, c.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b.GivesCheck(m) != c.checks {
+			t.Errorf("FEN: %s\nMove %s: expected check %t", c.fen, c.move, c.checks)
+		}
+
+		b.Variant = board.RacingKings
+		if b.Allows(m) == c.checks {
+			t.Errorf("FEN: %s\nMove %s: racing kings expected allowed %t", c.fen, c.move, !c.checks)
+		}
+	}
+
+	b := board.NewBoard()
+	b.Variant = board.RacingKings
+	if err := parseFEN(b, startFEN(board.RacingKings)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Validate(); err != nil {
+		t.Errorf("Racing kings start position: %s", err)
+	}
+
+	b = board.NewBoard()
+	b.Variant = board.RacingKings
+	if err := parseFEN(b, "8/8/8/8/8/8/k7/R6K w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if b.Validate() == nil {
+		t.Errorf("Expected a racing kings position with a check to be invalid")
+	}
+}
+
+func TestAtomicAntichess(t *testing.T) {
+	cases := []struct {
+		variant board.Variant
+		fen     string
+		move    string
+		allowed bool
+	}{
+		{board.Atomic, "4k3/8/8/8/8/8/3q4/4K3 w - - 0 1", "e1d2", false},
+		{board.Atomic, "4k3/8/8/8/8/8/3p4/3QK3 w - - 0 1", "d1d2", false},
+		{board.Atomic, "4k3/8/8/8/3p4/8/8/3QK3 w - - 0 1", "d1d4", true},
+		{board.Atomic, "4k3/4r3/8/8/8/8/8/4QK2 w - - 0 1", "e1e7", true},
+		{board.Antichess, "4k3/8/8/8/8/8/3p4/3QK3 w - - 0 1", "d1d2", true},
+		{board.Antichess, "4k3/8/8/8/8/8/3p4/3QK3 w - - 0 1", "e1d2", true},
+		{board.Antichess, "4k3/8/8/8/8/8/3p4/3QK3 w - - 0 1", "e1f1", false},
+		{board.Antichess, "4k3/8/8/8/8/8/3p4/3QK3 w - - 0 1", "d1a4", false},
+		{board.Antichess, "8/8/8/3pP3/8/8/8/8 w - d6 0 1", "e5e6", false},
+		{board.Antichess, "8/8/8/3pP3/8/8/8/8 w - d6 0 1", "e5d6", true},
+		{board.Antichess, "3r4/4P3/8/8/8/8/8/8 w - - 0 1", "e7d8k", true},
+		{board.Antichess, "3r4/4P3/8/8/8/8/8/8 w - - 0 1", "e7e8k", false},
+		{board.Antichess, "8/4P3/8/8/8/8/8/8 w - - 0 1", "e7e8k", true},
+	}
+
+	for _, c := range cases {
+		b := board.NewBoard()
+		b.Variant = c.variant
+		if err := parseFEN(b, c.fen); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Validate(); err != nil {
+			t.Errorf("%s %s: %s", c.variant, c.fen, err)
+		}
+
+		m, err := board.ParseUCIMove(b, c.move)
+		if err != nil {
+			t.Errorf("%s %s: %s", c.variant, c.fen, err)
+			continue
+		}
+
+		if m.UCI() != c.move || b.Allows(m) != c.allowed {
+			t.Errorf("%s %s\nMove %s: expected allowed %t found %s", c.variant, c.fen, c.move, c.allowed, m)
+		}
+	}
+
+	b := board.NewBoard()
+	b.Variant = board.Atomic
+	if err := parseFEN(b, "8/8/8/8/8/8/3kK3/8 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if b.InCheck(board.WhiteTurn) || b.InCheck(board.BlackTurn) {
+		t.Errorf("Expected touching atomic kings to not be in check")
+	}
+
+	results := []struct {
+		variant board.Variant
+		fen     string
+		result  board.GameResult
+		over    bool
+	}{
+		{board.Atomic, "8/8/8/8/8/8/8/4K3 b - - 0 1", board.WhiteWin, true},
+		{board.Atomic, "4k3/8/8/8/8/8/8/4K3 w - - 0 1", board.Draw, false},
+		{board.Antichess, "8/8/8/8/8/8/8/4K3 b - - 0 1", board.BlackWin, true},
+		{board.Antichess, "4k3/8/8/8/8/8/8/4K3 w - - 0 1", board.Draw, false},
+		{board.Antichess, "4kk2/8/8/8/8/8/8/8 b - - 0 1", board.WhiteWin, true},
+	}
+
+	for _, c := range results {
+		b := board.NewBoard()
+		b.Variant = c.variant
+		if err := parseFEN(b, c.fen); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Validate(); err != nil {
+			t.Errorf("%s %s: %s", c.variant, c.fen, err)
+		}
+
+		result, over := b.Result()
+		if over != c.over || (over && result != c.result) {
+			t.Errorf("%s %s: expected %s (%t) found %s (%t)", c.variant, c.fen, c.result, c.over, result, over)
+		}
+	}
+
+	b = board.NewBoard()
+	b.Variant = board.Antichess
+	if err := parseFEN(b, startPosFEN); err != nil {
+		t.Fatal(err)
+	}
+	if b.Validate() == nil {
+		t.Errorf("Expected castling rights to be invalid in antichess")
+	}
+
+	for _, variant := range []board.Variant{board.Standard, board.Atomic, board.Antichess, board.Horde} {
+		b := board.NewBoard()
+		b.Variant = variant
+		if err := parseFEN(b, startFEN(variant)); err != nil {
+			t.Fatal(err)
+		}
+
+		b.Checks[0] = 1
+		if b.Validate() == nil {
+			t.Errorf("%s: expected check counts to be invalid outside three-check", variant)
+		}
+	}
+}
+
+func TestHorde(t *testing.T) {
+	b := board.NewBoard()
+	b.Variant = board.Horde
+	if err := parseFEN(b, startFEN(board.Horde)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if count := bits.OnesCount64(b.Bitboards[board.White_pawn]); count != 36 {
+		t.Errorf("Expected 36 white pawns found %d", count)
+	}
+	if b.InCheck(board.WhiteTurn) {
+		t.Errorf("Expected the king-less side to never be in check")
+	}
+
+	pushes := []struct {
+		fen   string
+		valid bool
+	}{
+		{"4k3/8/8/8/8/8/8/P7 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/P7/P7 w - - 0 1", false},
+		{"4k3/8/8/8/8/P7/8/P7 w - - 0 1", false},
+	}
+
+	for _, c := range pushes {
+		b := board.NewBoard()
+		b.Variant = board.Horde
+		if err := parseFEN(b, c.fen); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := board.ParseUCIMove(b, "a1a3")
+		if c.valid && (err != nil || m.Flags() != int(board.QuietMoveFlag)) {
+			t.Errorf("FEN: %s\nExpected a1a3 to be a quiet push found %s %v", c.fen, m, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("FEN: %s\nExpected a1a3 to be blocked found %s", c.fen, m)
+		}
+	}
+
+	b = board.NewBoard()
+	b.Variant = board.Horde
+	if err := parseFEN(b, "4k3/8/8/8/1p6/P7/8/8 b - a2 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if b.Validate() == nil {
+		t.Errorf("Expected an en passant square behind a first rank double push to be invalid")
+	}
+
+	b = board.NewBoard()
+	b.Variant = board.Horde
+	if err := parseFEN(b, "4k3/8/8/8/8/8/8/8 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if result, over := b.Result(); !over || result != board.BlackWin {
+		t.Errorf("Expected black to win once white has no pieces found %s (%t)", result, over)
+	}
+
+	b = board.NewBoard()
+	b.Variant = board.Horde
+	if err := parseFEN(b, startPosFEN); err != nil {
+		t.Fatal(err)
+	}
+	if b.Validate() == nil {
+		t.Errorf("Expected a white king to be invalid in horde")
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type OptionType uint8
+
+const (
+	SpinOption OptionType = iota
+	CheckOption
+	ComboOption
+	StringOption
+	ButtonOption
+)
+
+func (t OptionType) String() string {
+	switch t {
+	case SpinOption:
+		return "spin"
+	case CheckOption:
+		return "check"
+	case ComboOption:
+		return "combo"
+	case StringOption:
+		return "string"
+	case ButtonOption:
+		return "button"
+	default:
+		return "?"
+	}
+}
+
+type Option struct {
+	Name    string
+	Type    OptionType
+	Default string
+	Min     int
+	Max     int
+	Vars    []string
+	Value   string
+
+	// NOTE: called after Value is updated, a returned error rolls the value back
+	OnChange func(o *Option) error
+}
+
+func NewSpinOption(name string, def int, min int, max int, onChange func(o *Option) error) *Option {
+	return &Option{
+		Name:     name,
+		Type:     SpinOption,
+		Default:  strconv.Itoa(def),
+		Min:      min,
+		Max:      max,
+		Value:    strconv.Itoa(def),
+		OnChange: onChange,
+	}
+}
+
+func NewCheckOption(name string, def bool, onChange func(o *Option) error) *Option {
+	return &Option{
+		Name:     name,
+		Type:     CheckOption,
+		Default:  strconv.FormatBool(def),
+		Value:    strconv.FormatBool(def),
+		OnChange: onChange,
+	}
+}
+
+func NewComboOption(name string, def string, vars []string, onChange func(o *Option) error) *Option {
+	return &Option{
+		Name:     name,
+		Type:     ComboOption,
+		Default:  def,
+		Vars:     vars,
+		Value:    def,
+		OnChange: onChange,
+	}
+}
+
+func NewStringOption(name string, def string, onChange func(o *Option) error) *Option {
+	return &Option{
+		Name:     name,
+		Type:     StringOption,
+		Default:  def,
+		Value:    def,
+		OnChange: onChange,
+	}
+}
+
+func NewButtonOption(name string, onChange func(o *Option) error) *Option {
+	return &Option{
+		Name:     name,
+		Type:     ButtonOption,
+		OnChange: onChange,
+	}
+}
+
+func (o *Option) Int() int {
+	value, _ := strconv.Atoi(o.Value)
+	return value
+}
+
+func (o *Option) Bool() bool {
+	return o.Value == "true"
+}
+
+func (o *Option) String() string {
+	var builder strings.Builder
+
+	builder.WriteString("option name ")
+	builder.WriteString(o.Name)
+	builder.WriteString(" type ")
+	builder.WriteString(o.Type.String())
+
+	switch o.Type {
+	case SpinOption:
+		{
+			fmt.Fprintf(&builder, " default %s min %d max %d", o.Default, o.Min, o.Max)
+		}
+	case CheckOption:
+		{
+			fmt.Fprintf(&builder, " default %s", o.Default)
+		}
+	case ComboOption:
+		{
+			fmt.Fprintf(&builder, " default %s", o.Default)
+			for _, v := range o.Vars {
+				builder.WriteString(" var ")
+				builder.WriteString(v)
+			}
+		}
+	case StringOption:
+		{
+			def := o.Default
+			if def == "" {
+				def = "<empty>"
+			}
+			fmt.Fprintf(&builder, " default %s", def)
+		}
+	}
+
+	return builder.String()
+}
+
+// validate returns the value as it should be stored, combo values are matched
+// case insensitively and stored as declared
+func (o *Option) validate(value string) (string, error) {
+	switch o.Type {
+	case SpinOption:
+		{
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return "", fmt.Errorf("Invalid value for %s: expected integer found %s", o.Name, value)
+			}
+
+			if n < o.Min || n > o.Max {
+				return "", fmt.Errorf("Invalid value for %s: expected %d-%d found %d", o.Name, o.Min, o.Max, n)
+			}
+
+			return strconv.Itoa(n), nil
+		}
+	case CheckOption:
+		{
+			switch strings.ToLower(value) {
+			case "true":
+				return "true", nil
+			case "false":
+				return "false", nil
+			default:
+				return "", fmt.Errorf("Invalid value for %s: expected true or false found %s", o.Name, value)
+			}
+		}
+	case ComboOption:
+		{
+			for _, v := range o.Vars {
+				if strings.EqualFold(v, value) {
+					return v, nil
+				}
+			}
+
+			return "", fmt.Errorf("Invalid value for %s: expected one of %s found %s", o.Name, strings.Join(o.Vars, ", "), value)
+		}
+	case StringOption:
+		{
+			if value == "<empty>" {
+				return "", nil
+			}
+
+			return value, nil
+		}
+	case ButtonOption:
+		{
+			return "", nil
+		}
+	default:
+		{
+			return "", fmt.Errorf("Invalid option type for %s: %d", o.Name, o.Type)
+		}
+	}
+}
+
+type Options struct {
+	list   []*Option
+	byName map[string]*Option
+}
+
+func NewOptions() *Options {
+	return &Options{
+		byName: map[string]*Option{},
+	}
+}
+
+// NOTE: UCI option names are case insensitive
+func (o *Options) Add(option *Option) {
+	o.list = append(o.list, option)
+	o.byName[strings.ToLower(option.Name)] = option
+}
+
+func (o *Options) Get(name string) *Option {
+	return o.byName[strings.ToLower(name)]
+}
+
+func (o *Options) Set(name string, value string) error {
+	option := o.Get(name)
+	if option == nil {
+		return fmt.Errorf("Unknown option: %s", name)
+	}
+
+	validated, err := option.validate(value)
+	if err != nil {
+		return err
+	}
+
+	old := option.Value
+	option.Value = validated
+
+	if option.OnChange != nil {
+		if err := option.OnChange(option); err != nil {
+			option.Value = old
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *Options) Print(w io.Writer) {
+	for _, option := range o.list {
+		fmt.Fprintln(w, option)
+	}
+}
+
+// parseSetOption splits "setoption name <id> [value <x>]", both the id and the
+// value may contain spaces, the value is kept as written apart from the
+// whitespace around it
+func parseSetOption(command string) (name string, value string, err error) {
+	parts := strings.Fields(command)
+	if len(parts) < 3 || parts[1] != "name" {
+		return "", "", fmt.Errorf("Invalid setoption: expected setoption name <id> [value <x>]")
+	}
+
+	// NOTE: offset follows the fields through command so the value can be cut from the raw text
+	valueIndex, offset := len(parts), 0
+	for i, part := range parts {
+		offset += strings.Index(command[offset:], part) + len(part)
+
+		if i >= 2 && part == "value" {
+			valueIndex = i
+			value = strings.TrimSpace(command[offset:])
+			break
+		}
+	}
+
+	name = strings.Join(parts[2:valueIndex], " ")
+	if name == "" {
+		return "", "", fmt.Errorf("Invalid setoption: missing option name")
+	}
+
+	return name, value, nil
+}
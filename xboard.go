@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neet-007/chess_engine_go/internal/board"
+)
+
+type xboardState struct {
+	protover int
+	force    bool
+	post     bool
+	analyze  bool
+	result   string
+
+	movesPerSession int
+	baseTime        time.Duration
+	increment       time.Duration
+	moveTime        time.Duration
+	maxDepth        int
+	engineTime      time.Duration
+	opponentTime    time.Duration
+}
+
+var xboardFeatures = []string{
+	"ping=1",
+	"setboard=1",
+	"usermove=1",
+	"time=1",
+	"draw=0",
+	"sigint=0",
+	"sigterm=0",
+	"reuse=1",
+	// NOTE: analyze=1 once there is a search to send thinking output from
+	"analyze=0",
+	"colors=0",
+	"san=0",
+	"variants=\"normal\"",
+}
+
+func (e *Engine) xboardError(kind string, command string) {
+	fmt.Fprintf(e.out, "Error (%s): %s\n", kind, command)
+}
+
+// parseLevelBase parses the base time of "level", either minutes or minutes:seconds
+func parseLevelBase(base string) (time.Duration, error) {
+	minutes, seconds, found := strings.Cut(base, ":")
+
+	m, err := strconv.Atoi(minutes)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid level base: expected minutes or minutes:seconds found %s", base)
+	}
+
+	total := time.Duration(m) * time.Minute
+	if found {
+		s, err := strconv.Atoi(seconds)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid level base: expected minutes or minutes:seconds found %s", base)
+		}
+
+		total += time.Duration(s) * time.Second
+	}
+
+	return total, nil
+}
+
+func parseSeconds(value string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("Invalid seconds: %s", value)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func parseCentiseconds(value string) (time.Duration, error) {
+	centiseconds, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid centiseconds: %s", value)
+	}
+
+	return time.Duration(centiseconds) * 10 * time.Millisecond, nil
+}
+
+func readXBoard(engine *Engine, in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	scanner.Split(bufio.ScanLines)
+
+	state := xboardState{}
+
+	for scanner.Scan() {
+		command := scanner.Text()
+
+		parts := strings.Fields(command)
+		if len(parts) == 0 {
+			continue
+		}
+
+		switch parts[0] {
+		case "xboard":
+			{
+			}
+		case "protover":
+			{
+				if len(parts) < 2 {
+					engine.xboardError("missing version", command)
+					continue
+				}
+
+				version, err := strconv.Atoi(parts[1])
+				if err != nil {
+					engine.xboardError("invalid version", command)
+					continue
+				}
+
+				state.protover = version
+				if version < 2 {
+					continue
+				}
+
+				fmt.Fprintf(engine.out, "feature done=0\n")
+				fmt.Fprintf(engine.out, "feature myname=\"%s\"\n", engine.id)
+				for _, feature := range xboardFeatures {
+					fmt.Fprintf(engine.out, "feature %s\n", feature)
+				}
+				fmt.Fprintf(engine.out, "feature done=1\n")
+			}
+		case "accepted", "rejected":
+			{
+			}
+		case "ping":
+			{
+				if len(parts) < 2 {
+					engine.xboardError("missing argument", command)
+					continue
+				}
+
+				fmt.Fprintf(engine.out, "pong %s\n", parts[1])
+			}
+		case "new":
+			{
+				b := board.NewBoard()
+				if err := parseFEN(b, startPosFEN); err != nil {
+					engine.xboardError(err.Error(), command)
+					continue
+				}
+
+				engine.board = b
+				state.force = false
+				state.analyze = false
+				state.result = ""
+				state.maxDepth = 0
+			}
+		case "force":
+			{
+				state.force = true
+			}
+		case "setboard":
+			{
+				b := board.NewBoard()
+				if err := parseFEN(b, strings.Join(parts[1:], " ")); err != nil {
+					fmt.Fprintf(engine.out, "tellusererror Illegal position\n")
+					continue
+				}
+
+				engine.board = b
+			}
+		case "usermove":
+			{
+				if len(parts) < 2 {
+					engine.xboardError("missing move", command)
+					continue
+				}
+
+				// TODO: validate and make the move once the board has a move generator
+				engine.xboardError("moves are not supported yet", command)
+			}
+		case "go":
+			{
+				// TODO: leave force mode, search and send "move" once there is a search
+				engine.xboardError("search is not supported yet", command)
+			}
+		case "undo", "remove":
+			{
+				// TODO: keep a move history once usermove can make moves
+				engine.xboardError("no moves to undo", command)
+			}
+		case "level":
+			{
+				if len(parts) != 4 {
+					engine.xboardError("expected level MPS BASE INC", command)
+					continue
+				}
+
+				mps, err := strconv.Atoi(parts[1])
+				if err != nil {
+					engine.xboardError("invalid moves per session", command)
+					continue
+				}
+
+				base, err := parseLevelBase(parts[2])
+				if err != nil {
+					engine.xboardError(err.Error(), command)
+					continue
+				}
+
+				increment, err := parseSeconds(parts[3])
+				if err != nil {
+					engine.xboardError(err.Error(), command)
+					continue
+				}
+
+				state.movesPerSession = mps
+				state.baseTime = base
+				state.increment = increment
+				state.moveTime = 0
+			}
+		case "st":
+			{
+				if len(parts) < 2 {
+					engine.xboardError("missing time", command)
+					continue
+				}
+
+				moveTime, err := parseSeconds(parts[1])
+				if err != nil {
+					engine.xboardError(err.Error(), command)
+					continue
+				}
+
+				state.moveTime = moveTime
+			}
+		case "sd":
+			{
+				if len(parts) < 2 {
+					engine.xboardError("missing depth", command)
+					continue
+				}
+
+				depth, err := strconv.Atoi(parts[1])
+				if err != nil || depth < 1 {
+					engine.xboardError("invalid depth", command)
+					continue
+				}
+
+				state.maxDepth = depth
+			}
+		case "time", "otim":
+			{
+				if len(parts) < 2 {
+					engine.xboardError("missing time", command)
+					continue
+				}
+
+				t, err := parseCentiseconds(parts[1])
+				if err != nil {
+					engine.xboardError(err.Error(), command)
+					continue
+				}
+
+				if parts[0] == "time" {
+					state.engineTime = t
+				} else {
+					state.opponentTime = t
+				}
+			}
+		case "result":
+			{
+				if len(parts) < 2 {
+					engine.xboardError("missing result", command)
+					continue
+				}
+
+				state.result = parts[1]
+				state.force = true
+				state.analyze = false
+			}
+		case "post":
+			{
+				state.post = true
+			}
+		case "nopost":
+			{
+				state.post = false
+			}
+		case "analyze":
+			{
+				// TODO: set state.analyze and send thinking output once there is a search
+				engine.xboardError("search is not supported yet", command)
+			}
+		case "exit":
+			{
+				if !state.analyze {
+					engine.xboardError("not analyzing", command)
+					continue
+				}
+
+				state.analyze = false
+			}
+		case "hard", "easy", "random", "computer", "name", "rating", "ics", "draw", ".":
+			{
+			}
+		case "quit":
+			{
+				return
+			}
+		default:
+			{
+				engine.xboardError("unknown command", command)
+			}
+		}
+	}
+}